@@ -7,6 +7,7 @@ mkdir -p $BUILD_DIR/configs
 echo "Building Go backend for Linux..."
 # 交叉编译环境设置
 CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -o $BUILD_DIR/mclogs-api ./cmd/server/main.go
+CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -o $BUILD_DIR/mclogs-rules ./cmd/rules
 
 echo "Copying configurations..."
 cp configs/config.yaml $BUILD_DIR/configs/config.yaml
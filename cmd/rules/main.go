@@ -0,0 +1,227 @@
+// Command rules helps writing configs/patterns.yaml: it lints the file, runs
+// the examples attached to detectors and rules, and explains how a log is
+// analyzed. It uses the same engine as the server.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"mclogs-go/internal/models"
+	"mclogs-go/internal/parser"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `Usage: rules <command> [flags] [args]
+
+Commands:
+  lint              check the patterns file for mistakes
+  test              run the examples and counter_examples of every detector and rule
+  explain <log>     show how a log file is analyzed ("-" reads stdin)
+
+Flags:
+  -patterns path    patterns file (default configs/patterns.yaml)
+  -lang locale      explain: language of the problems (default: the fallback locale)
+  -v                explain: also list rules and detectors that did not match
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cmd := os.Args[1]
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	patterns := fs.String("patterns", "configs/patterns.yaml", "patterns file")
+	lang := fs.String("lang", "", "locale of the problems")
+	verbose := fs.Bool("v", false, "show everything")
+	fs.Parse(os.Args[2:])
+
+	var err error
+	switch cmd {
+	case "lint":
+		err = lint(*patterns)
+	case "test":
+		err = test(*patterns)
+	case "explain":
+		if fs.NArg() != 1 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		err = explain(*patterns, fs.Arg(0), *lang, *verbose)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func lint(path string) error {
+	config, err := parser.LoadPatterns(path)
+	if err != nil {
+		return err
+	}
+
+	errors, warnings := 0, 0
+	for _, issue := range parser.Lint(config) {
+		fmt.Println(issue)
+		if issue.Warning {
+			warnings++
+		} else {
+			errors++
+		}
+	}
+	fmt.Printf("%d detectors, %d rules: %d errors, %d warnings\n",
+		len(config.Detectors), len(config.Analyzers), errors, warnings)
+	if errors > 0 {
+		return fmt.Errorf("%s has errors", path)
+	}
+	return nil
+}
+
+func test(path string) error {
+	config, err := parser.LoadPatterns(path)
+	if err != nil {
+		return err
+	}
+	engine, err := parser.NewEngine(path)
+	if err != nil {
+		return err
+	}
+
+	examples := 0
+	for _, det := range config.Detectors {
+		examples += len(det.Examples) + len(det.CounterExamples)
+	}
+	for _, rule := range config.Analyzers {
+		examples += len(rule.Examples) + len(rule.CounterExamples)
+	}
+
+	failures := engine.CheckFixtures()
+	for _, f := range failures {
+		fmt.Println("FAIL", f.Error())
+	}
+	fmt.Printf("%d examples, %d failed\n", examples, len(failures))
+	if len(failures) > 0 {
+		return fmt.Errorf("%d examples failed", len(failures))
+	}
+	return nil
+}
+
+func explain(path, logPath, lang string, verbose bool) error {
+	engine, err := parser.NewEngine(path)
+	if err != nil {
+		return err
+	}
+
+	var content []byte
+	if logPath == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(logPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	x := engine.Explain(string(content), lang)
+	total := time.Since(start)
+	res := x.Result
+
+	fmt.Printf("Type:        %s (%s), confidence %.2f\n", res.Type, res.Name, res.Confidence)
+	fmt.Printf("Version:     %s\n", res.Version)
+	fmt.Printf("Locale:      %s\n", res.Locale)
+	fmt.Printf("Extracted:   %d exceptions, %d mods, %d plugins, %d info entries\n",
+		len(res.Exceptions), len(res.Mods), len(res.Plugins), len(res.Information))
+	fmt.Printf("Total time:  %s\n", total)
+
+	fmt.Println("\nDetectors:")
+	dets := x.Detectors
+	sort.SliceStable(dets, func(i, j int) bool { return dets[i].Score > dets[j].Score })
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, d := range dets {
+		if d.Score == 0 && !verbose {
+			continue
+		}
+		fmt.Fprintf(w, "  %g\t%s\t%s\n", d.Score, d.Type, d.Name)
+		for _, s := range d.Signals {
+			mark := "-"
+			if s.Matched {
+				mark = "+"
+			}
+			if s.Matched || verbose {
+				fmt.Fprintf(w, "\t%s %g\t%s\n", mark, s.Weight, s.Pattern)
+			}
+		}
+	}
+	w.Flush()
+
+	fmt.Println("\nRules:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, r := range x.Rules {
+		if !r.Matched && !verbose {
+			continue
+		}
+		status := "no match"
+		if r.Skipped {
+			status = "skipped (literals not in log)"
+		} else if r.Matched {
+			status = "lines " + formatLines(r.Lines)
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", r.Name, status, r.Duration)
+	}
+	w.Flush()
+
+	fmt.Println("\nProblems:")
+	for _, p := range res.Problems {
+		fmt.Printf("  [%s] %s\n", p.Severity, p.Message)
+		for _, s := range p.Solutions {
+			fmt.Printf("      - %s\n", s.Message)
+			if a := s.Action; a != nil {
+				fmt.Printf("        %s\n", formatAction(a))
+			}
+		}
+	}
+	return nil
+}
+
+func formatAction(a *models.SolutionAction) string {
+	var parts []string
+	for _, f := range []struct{ name, value string }{
+		{"label", a.Label}, {"url", a.URL}, {"file", a.File}, {"key", a.Key}, {"value", a.Value},
+		{"mod", a.Mod}, {"plugin", a.Plugin}, {"flag", a.Flag},
+	} {
+		if f.value != "" {
+			parts = append(parts, f.name+"="+strconv.Quote(f.value))
+		}
+	}
+	return "[" + a.Type + "] " + strings.Join(parts, " ")
+}
+
+// formatLines lists the first few line numbers of a match.
+func formatLines(lines []int) string {
+	const max = 10
+	if len(lines) == 0 {
+		return "-"
+	}
+	var parts []string
+	for i, l := range lines {
+		if i == max {
+			parts = append(parts, fmt.Sprintf("(+%d more)", len(lines)-max))
+			break
+		}
+		parts = append(parts, fmt.Sprint(l))
+	}
+	return strings.Join(parts, ", ")
+}
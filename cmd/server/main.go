@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"mclogs-go/internal/api"
@@ -8,16 +9,23 @@ import (
 	"mclogs-go/internal/config"
 	"mclogs-go/internal/parser"
 	"mclogs-go/internal/storage"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// patternsPollInterval is how often the patterns file is checked for changes.
+const patternsPollInterval = 10 * time.Second
+
 func main() {
 	// Load config
 	cfg, err := config.LoadConfig("configs/config.yaml")
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if !cfg.URLs.TrustProxy && (cfg.URLs.BaseURL == "" || cfg.URLs.APIBaseURL == "") {
+		log.Println("urls.base_url and urls.api_base_url are not both set and urls.trust_proxy is off; behind a TLS proxy links will use http")
+	}
 
 	// Initialize Storage
 	var store storage.Storage
@@ -38,11 +46,27 @@ func main() {
 	}
 
 	// Initialize Cache
-	var c cache.Cache
+	var c cache.Cache = cache.NoopCache{}
 	if cfg.Cache.Enabled {
-		if cfg.Cache.Driver == "redis" {
+		switch cfg.Cache.Driver {
+		case "redis":
 			c = cache.NewRedisCache(cfg)
+		case "memory":
+			c = cache.NewMemoryCache(cfg.Cache.MaxSize)
+		case "mongodb":
+			c, err = cache.NewMongoCache(cfg)
+			if err != nil {
+				log.Fatalf("Failed to initialize MongoDB cache: %v", err)
+			}
+		case "tiered":
+			// Local LRU per replica, Redis shared between them
+			local := cache.NewMemoryCache(cfg.Cache.MaxSize)
+			localTTL := time.Duration(cfg.Cache.LocalTTL) * time.Second
+			c = cache.NewTieredCache(context.Background(), local, cache.NewRedisCache(cfg), localTTL)
+		default:
+			log.Fatalf("Unknown cache driver %q", cfg.Cache.Driver)
 		}
+		log.Printf("Initialized %s cache", cfg.Cache.Driver)
 	}
 
 	// Initialize Parser
@@ -50,12 +74,23 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to initialize Parser: %v", err)
 	}
+	// Pick up edits to the patterns file; cached insights are keyed by its hash
+	go p.Watch(context.Background(), patternsPollInterval)
 
 	// Set up Router
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	r := gin.Default()
+	// client IPs, which password attempts are limited by, only come from
+	// X-Forwarded-For when it was set by one of the trusted proxies
+	var trustedProxies []string
+	if cfg.URLs.TrustProxy {
+		trustedProxies = cfg.URLs.TrustedProxies
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid urls.trusted_proxies: %v", err)
+	}
 
 	// Add CORS middleware
 	if cfg.Server.CORS.Enabled {
@@ -89,7 +124,7 @@ func main() {
 				c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 			}
 			
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Log-Password, X-Access-Token")
 			c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 			if c.Request.Method == "OPTIONS" {
@@ -106,12 +141,21 @@ func main() {
 
 	// Routes
 	v1 := r.Group("/1")
+	v1.Use(api.DecodeBody(cfg))
 	{
 		v1.POST("/log", h.CreateLog)
+		v1.POST("/analyse", h.Analyse)
 		v1.GET("/log/:id", h.GetLog)
 		v1.GET("/insights/:id", h.GetLog)
 		v1.GET("/ai-analysis/:id", h.GetAIAnalysis) // Add this line to fix 404 for AI analysis
 		v1.GET("/raw/:id", h.GetRawLog)
+		v1.POST("/unlock/:id", h.UnlockLog)
+		v1.DELETE("/delete/:id", h.DeleteLog)
+		v1.POST("/bundle", h.CreateBundle)
+		v1.GET("/bundle/:id", h.GetBundle)
+		v1.GET("/bundle/:id/insights", h.GetBundleInsights)
+		v1.GET("/cache/stats", h.RequireAdmin, h.GetCacheStats)
+		v1.GET("/limits", h.GetLimits)
 	}
 
 	// Start Server
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mclogs-go/internal/cache"
+	"mclogs-go/internal/config"
+	"mclogs-go/internal/models"
+	"mclogs-go/internal/parser"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// memStore is an in-memory storage.Storage for handler tests.
+type memStore struct {
+	mu      sync.Mutex
+	logs    map[string]*models.Log
+	bundles map[string]*models.Bundle
+	next    int
+	// failPuts makes Put fail once that many logs are stored; failBundles
+	// makes PutBundle fail
+	failPuts    int
+	failBundles bool
+}
+
+func newMemStore() *memStore {
+	return &memStore{logs: map[string]*models.Log{}, bundles: map[string]*models.Bundle{}}
+}
+
+func (s *memStore) Put(ctx context.Context, log *models.Log) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failPuts > 0 && len(s.logs) >= s.failPuts {
+		return "", errors.New("storage is full")
+	}
+	s.next++
+	log.ID = fmt.Sprintf("log%d", s.next)
+	log.CreatedAt = time.Now()
+	stored := *log
+	s.logs[log.ID] = &stored
+	return log.ID, nil
+}
+
+func (s *memStore) Get(ctx context.Context, id string) (*models.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.live(id)
+	if l == nil {
+		return nil, nil
+	}
+	if l.MaxViews > 0 {
+		l.Views++
+		if l.Views >= l.MaxViews {
+			delete(s.logs, id)
+		}
+	}
+	read := *l
+	return &read, nil
+}
+
+func (s *memStore) Peek(ctx context.Context, id string) (*models.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.live(id)
+	if l == nil {
+		return nil, nil
+	}
+	read := *l
+	return &read, nil
+}
+
+// live returns the log unless it is missing or expired.
+func (s *memStore) live(id string) *models.Log {
+	l := s.logs[id]
+	if l == nil || !l.ExpiresAt.IsZero() && time.Now().After(l.ExpiresAt) {
+		return nil
+	}
+	return l
+}
+
+func (s *memStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.logs, id)
+	return nil
+}
+
+func (s *memStore) Renew(ctx context.Context, id string) error {
+	return nil
+}
+
+func (s *memStore) PutBundle(ctx context.Context, bundle *models.Bundle) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failBundles {
+		return "", errors.New("storage is full")
+	}
+	s.next++
+	bundle.ID = fmt.Sprintf("bundle%d", s.next)
+	bundle.CreatedAt = time.Now()
+	stored := *bundle
+	s.bundles[bundle.ID] = &stored
+	return bundle.ID, nil
+}
+
+func (s *memStore) GetBundle(ctx context.Context, id string) (*models.Bundle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.bundles[id]
+	if b == nil {
+		return nil, nil
+	}
+	read := *b
+	return &read, nil
+}
+
+func (s *memStore) DeleteBundle(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.bundles, id)
+	return nil
+}
+
+// newTestServer serves the API routes from a handler on store, without a
+// cache.
+func newTestServer(t *testing.T, store *memStore, cfg *config.Config) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	p, err := parser.NewParser("../../configs/patterns.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Secret == "" {
+		cfg.Server.Secret = "test secret"
+	}
+	h := NewHandler(store, cache.NoopCache{}, p, cfg)
+
+	r := gin.New()
+	r.Use(DecodeBody(cfg))
+	v1 := r.Group("/1")
+	v1.POST("/log", h.CreateLog)
+	v1.POST("/analyse", h.Analyse)
+	v1.GET("/log/:id", h.GetLog)
+	v1.GET("/raw/:id", h.GetRawLog)
+	v1.POST("/unlock/:id", h.UnlockLog)
+	v1.DELETE("/delete/:id", h.DeleteLog)
+	v1.POST("/bundle", h.CreateBundle)
+	v1.GET("/bundle/:id", h.GetBundle)
+	return r
+}
+
+// TestAnalyse checks that a posted log is analyzed but not stored.
+func TestAnalyse(t *testing.T) {
+	store := newMemStore()
+	r := newTestServer(t, store, &config.Config{})
+
+	content := "[12:00:00] [main/ERROR]: Failed to start\n" +
+		"java.lang.NoClassDefFoundError: com/example/lib/Config\n" +
+		"\tat com.example.mod.Main.init(Main.java:10)\n"
+	req := httptest.NewRequest(http.MethodPost, "/1/analyse", strings.NewReader(url.Values{"content": {content}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var analysis models.AnalysisResult
+	if err := json.Unmarshal(w.Body.Bytes(), &analysis); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("status %d, %s", w.Code, w.Body)
+	}
+	if len(analysis.Exceptions) != 1 || analysis.Exceptions[0].Class != "java.lang.NoClassDefFoundError" {
+		t.Errorf("exceptions = %+v, want the NoClassDefFoundError", analysis.Exceptions)
+	}
+	if len(analysis.Problems) == 0 {
+		t.Error("no problems found")
+	}
+	if analysis.ID != "" {
+		t.Errorf("the analysis has ID %q, want none", analysis.ID)
+	}
+	if len(store.logs) != 0 || store.next != 0 {
+		t.Errorf("%d logs stored, want none", len(store.logs))
+	}
+}
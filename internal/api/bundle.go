@@ -0,0 +1,250 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mclogs-go/internal/models"
+	"mclogs-go/internal/parser"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBundleFiles is how many files one bundle can hold.
+const maxBundleFiles = 10
+
+// maxBundleFileName is the longest file name kept, in bytes.
+const maxBundleFileName = 255
+
+type bundleFile struct {
+	Name    string `json:"name"`
+	Content string `json:"content" binding:"required"`
+}
+
+// bindBundle reads the files of a bundle upload, either as multipart "files"
+// parts or as JSON {"files": [{"name", "content"}]}, in upload order, and
+// the optional expires_in. If that fails, it answers the request and
+// returns false.
+func (h *Handler) bindBundle(c *gin.Context) ([]bundleFile, int64, bool) {
+	var files []bundleFile
+	var expiresIn int64
+
+	if c.ContentType() == gin.MIMEMultipartPOSTForm {
+		form, err := c.MultipartForm()
+		if err != nil {
+			bindError(c, err)
+			return nil, 0, false
+		}
+		if v := form.Value["expires_in"]; len(v) > 0 {
+			if expiresIn, err = strconv.ParseInt(v[0], 10, 64); err != nil || expiresIn < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in must be a number of seconds"})
+				return nil, 0, false
+			}
+		}
+		for _, fh := range form.File["files"] {
+			f, err := fh.Open()
+			if err != nil {
+				bindError(c, err)
+				return nil, 0, false
+			}
+			content, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				bindError(c, err)
+				return nil, 0, false
+			}
+			files = append(files, bundleFile{Name: fh.Filename, Content: string(content)})
+		}
+	} else {
+		var req struct {
+			Files     []bundleFile `json:"files" binding:"dive"`
+			ExpiresIn int64        `json:"expires_in" binding:"min=0"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return nil, 0, false
+		}
+		files, expiresIn = req.Files, req.ExpiresIn
+	}
+
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A bundle needs at least one file"})
+		return nil, 0, false
+	}
+	if len(files) > maxBundleFiles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("A bundle can hold at most %d files", maxBundleFiles)})
+		return nil, 0, false
+	}
+
+	for i := range files {
+		files[i].Name = bundleFileName(files[i].Name, i)
+		content, err := h.filterContent(files[i].Content)
+		if err != nil {
+			log.Printf("[API] Error filtering %s: %v", files[i].Name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to filter log"})
+			return nil, 0, false
+		}
+		files[i].Content = content
+	}
+	return files, expiresIn, true
+}
+
+// bundleFileName keeps the base name of an uploaded file, which clients
+// may send with its path.
+func bundleFileName(name string, i int) string {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return fmt.Sprintf("file-%d.log", i+1)
+	}
+	if len(name) > maxBundleFileName {
+		name = strings.ToValidUTF8(name[:maxBundleFileName], "")
+	}
+	return name
+}
+
+// CreateBundle stores several related logs, each as a log of its own, and a
+// bundle listing them. One delete token is returned, which deletes any of
+// the files; the bundle goes with the last of them. If storing fails, the
+// files already stored are deleted again.
+func (h *Handler) CreateBundle(c *gin.Context) {
+	files, expiresIn, ok := h.bindBundle(c)
+	if !ok {
+		return
+	}
+
+	token, tokenHash := newDeleteToken()
+	var expiresAt time.Time
+	if d := h.expiresIn(expiresIn); d > 0 {
+		expiresAt = time.Now().Add(d)
+	}
+
+	ctx := c.Request.Context()
+	bundle := &models.Bundle{ExpiresAt: expiresAt}
+	// even if the client went away, nothing should be left behind
+	cleanup := func() {
+		for _, f := range bundle.Files {
+			if err := h.storage.Delete(context.WithoutCancel(ctx), f.LogID); err != nil {
+				log.Printf("[API] Error deleting bundle file %s: %v", f.LogID, err)
+			}
+		}
+	}
+
+	links := make([]gin.H, 0, len(files))
+	for _, f := range files {
+		logData := &models.Log{Content: f.Content, DeleteTokenHash: tokenHash, ExpiresAt: expiresAt}
+		id, err := h.storage.Put(ctx, logData)
+		if err != nil {
+			log.Printf("[API] Error storing bundle file %s: %v", f.Name, err)
+			cleanup()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store log"})
+			return
+		}
+		// the bundle ends with its files
+		bundle.ExpiresAt = logData.ExpiresAt
+		bundle.Files = append(bundle.Files, models.BundleFile{Name: f.Name, LogID: id})
+
+		link := h.logURLs(c, id)
+		link["name"] = f.Name
+		link["id"] = id
+		links = append(links, link)
+	}
+
+	id, err := h.storage.PutBundle(ctx, bundle)
+	if err != nil {
+		log.Printf("[API] Error storing bundle: %v", err)
+		cleanup()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store bundle"})
+		return
+	}
+
+	api := h.apiBaseURL(c)
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"id":           id,
+		"url":          api + "/1/bundle/" + id,
+		"insights":     api + "/1/bundle/" + id + "/insights",
+		"files":        links,
+		"delete_token": token,
+	})
+}
+
+// bundle reads the bundle in the id parameter and the logs of its files.
+// Files deleted with the delete token are left out, and a bundle with none
+// left is deleted as well. If it is missing or can't be read, it answers
+// the request and returns nil.
+func (h *Handler) bundle(c *gin.Context) (*models.Bundle, []*models.Log) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+	bundle, err := h.storage.GetBundle(ctx, id)
+	if err != nil {
+		log.Printf("[API] Error retrieving bundle %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bundle"})
+		return nil, nil
+	}
+	if bundle == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bundle not found"})
+		return nil, nil
+	}
+
+	var files []models.BundleFile
+	var logs []*models.Log
+	for _, f := range bundle.Files {
+		logData, err := h.storage.Peek(ctx, f.LogID)
+		if err != nil {
+			log.Printf("[API] Error retrieving log %s: %v", f.LogID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve log"})
+			return nil, nil
+		}
+		if logData != nil {
+			files = append(files, f)
+			logs = append(logs, logData)
+		}
+	}
+	bundle.Files = files
+
+	if len(files) == 0 {
+		if err := h.storage.DeleteBundle(ctx, id); err != nil {
+			log.Printf("[API] Error deleting empty bundle %s: %v", id, err)
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bundle not found"})
+		return nil, nil
+	}
+	return bundle, logs
+}
+
+// GetBundle lists the files of a bundle that still exist.
+func (h *Handler) GetBundle(c *gin.Context) {
+	if bundle, _ := h.bundle(c); bundle != nil {
+		c.JSON(http.StatusOK, bundle)
+	}
+}
+
+// GetBundleInsights analyzes every file of a bundle that still exists and
+// merges the results, so e.g. the crash report's root cause and the mod
+// list from latest.log show together.
+func (h *Handler) GetBundleInsights(c *gin.Context) {
+	bundle, logs := h.bundle(c)
+	if bundle == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	locale := h.locale(c)
+	result := &models.BundleAnalysis{ID: bundle.ID, Files: []models.BundleFileAnalysis{}}
+	var analyses []*models.AnalysisResult
+	for i, f := range bundle.Files {
+		analysis := h.analyze(ctx, logs[i], locale)
+		result.Files = append(result.Files, models.BundleFileAnalysis{Name: f.Name, ID: f.LogID, Insights: analysis})
+		analyses = append(analyses, analysis)
+	}
+
+	result.Merged = parser.Merge(analyses...)
+	result.Merged.ID = bundle.ID
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"mclogs-go/internal/config"
+	"mclogs-go/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestCreateBundleCleanup checks that a bundle that can't be stored
+// completely leaves none of its files behind.
+func TestCreateBundleCleanup(t *testing.T) {
+	const body = `{"files": [
+		{"name": "latest.log", "content": "one"},
+		{"name": "debug.log", "content": "two"},
+		{"name": "crash.txt", "content": "three"}
+	]}`
+
+	tests := []struct {
+		name        string
+		failPuts    int
+		failBundles bool
+	}{
+		{"a file fails", 2, false},
+		{"the bundle fails", 0, true},
+	}
+	for _, tt := range tests {
+		store := newMemStore()
+		store.failPuts, store.failBundles = tt.failPuts, tt.failBundles
+		r := newTestServer(t, store, &config.Config{})
+
+		req := httptest.NewRequest(http.MethodPost, "/1/bundle", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("%s: status %d, want 500", tt.name, w.Code)
+		}
+		if len(store.logs) != 0 || len(store.bundles) != 0 {
+			t.Errorf("%s: %d logs and %d bundles left behind", tt.name, len(store.logs), len(store.bundles))
+		}
+	}
+}
+
+// TestBundleDeletedFiles checks that deleted files leave the bundle, and
+// the bundle goes with the last of them.
+func TestBundleDeletedFiles(t *testing.T) {
+	store := newMemStore()
+	r := newTestServer(t, store, &config.Config{})
+	do := func(method, target, body string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header = header
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/1/bundle", `{"files": [
+		{"name": "latest.log", "content": "one"},
+		{"name": "crash.txt", "content": "two"}
+	]}`, http.Header{"Content-Type": {"application/json"}})
+	var created struct {
+		ID          string
+		DeleteToken string `json:"delete_token"`
+		Files       []struct{ ID string }
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("upload: status %d, %s", w.Code, w.Body)
+	}
+	auth := http.Header{"Authorization": {"Bearer " + created.DeleteToken}}
+
+	if w := do(http.MethodDelete, "/1/delete/"+created.Files[0].ID, "", auth); w.Code != http.StatusOK {
+		t.Fatalf("deleting latest.log: status %d, %s", w.Code, w.Body)
+	}
+	w = do(http.MethodGet, "/1/bundle/"+created.ID, "", http.Header{})
+	var bundle models.Bundle
+	if err := json.Unmarshal(w.Body.Bytes(), &bundle); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("bundle: status %d, %s", w.Code, w.Body)
+	}
+	if len(bundle.Files) != 1 || bundle.Files[0].Name != "crash.txt" {
+		t.Errorf("bundle lists %+v, want only crash.txt", bundle.Files)
+	}
+
+	if w := do(http.MethodDelete, "/1/delete/"+created.Files[1].ID, "", auth); w.Code != http.StatusOK {
+		t.Fatalf("deleting crash.txt: status %d, %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodGet, "/1/bundle/"+created.ID, "", http.Header{}); w.Code != http.StatusNotFound {
+		t.Errorf("empty bundle: status %d, want 404", w.Code)
+	}
+	if len(store.bundles) != 0 {
+		t.Errorf("the empty bundle was not deleted")
+	}
+}
+
+// TestBundleBodySize checks that a bundle of several files of the largest
+// size one log may have is accepted, while a single log that size is the
+// most /1/log takes.
+func TestBundleBodySize(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Storage.MaxLength = 100 << 10
+	r := newTestServer(t, newMemStore(), cfg)
+	content := strings.Repeat("a", int(maxBodySize(cfg))-100)
+
+	var files []string
+	for i := range 3 {
+		files = append(files, fmt.Sprintf(`{"name": "%d.log", "content": %q}`, i, content))
+	}
+	req := httptest.NewRequest(http.MethodPost, "/1/bundle", strings.NewReader(`{"files": [`+strings.Join(files, ",")+`]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("bundle of 3 files: status %d, %s", w.Code, w.Body)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/1/log", strings.NewReader(`{"content": "`+content+content+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("log of twice the size: status %d, want 413", w.Code)
+	}
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mclogs-go/internal/config"
+	"mclogs-go/internal/filter"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaxBodySize limits request bodies when neither server.max_body_size
+// nor storage.max_length is set.
+const defaultMaxBodySize = 32 << 20
+
+// contentEncodings are the request Content-Encodings DecodeBody undoes, as
+// the PHP ContentParser did.
+var contentEncodings = []string{"deflate", "gzip", "x-gzip"}
+
+// maxBodySize is the largest request body accepted, before and after
+// decoding. By default it leaves room for a form-encoded log of
+// storage.max_length bytes, which can grow to three times that.
+func maxBodySize(cfg *config.Config) int64 {
+	if cfg.Server.MaxBodySize > 0 {
+		return cfg.Server.MaxBodySize
+	}
+	if cfg.Storage.MaxLength > 0 {
+		return 3*int64(cfg.Storage.MaxLength) + 64<<10
+	}
+	return defaultMaxBodySize
+}
+
+// maxBundleBodySize is the largest body of a bundle upload, which carries
+// up to maxBundleFiles logs.
+func maxBundleBodySize(cfg *config.Config) int64 {
+	return maxBundleFiles * maxBodySize(cfg)
+}
+
+// bundleRoute is the route CreateBundle is served on.
+const bundleRoute = "/1/bundle"
+
+// DecodeBody limits the size of request bodies and undoes a gzip or
+// deflate Content-Encoding, so handlers always bind plain content.
+func DecodeBody(cfg *config.Config) gin.HandlerFunc {
+	logLimit, bundleLimit := maxBodySize(cfg), maxBundleBodySize(cfg)
+	return func(c *gin.Context) {
+		limit := logLimit
+		if c.FullPath() == bundleRoute {
+			limit = bundleLimit
+		}
+		// net/http only lifts its 10 MiB cap on form bodies when the body
+		// is a *http.MaxBytesReader, so it must not be wrapped
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+		if header := c.GetHeader("Content-Encoding"); header != "" {
+			body := io.Reader(c.Request.Body)
+			steps := strings.Split(header, ",")
+			// encodings are listed in the order they were applied
+			for i := len(steps) - 1; i >= 0; i-- {
+				var err error
+				switch step := strings.ToLower(strings.TrimSpace(steps[i])); step {
+				case "deflate":
+					body = flate.NewReader(body)
+				case "gzip", "x-gzip":
+					body, err = gzip.NewReader(body)
+				case "identity":
+				default:
+					c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": "Unsupported Content-Encoding: " + step})
+					return
+				}
+				if err != nil {
+					bindError(c, err)
+					c.Abort()
+					return
+				}
+			}
+			c.Request.Header.Del("Content-Encoding")
+			c.Request.ContentLength = -1
+			// decoded content is limited as well, against compression bombs
+			c.Request.Body = http.MaxBytesReader(c.Writer, io.NopCloser(body), limit)
+		}
+
+		c.Next()
+	}
+}
+
+// uploadRequest is the body of POST /1/log and /1/analyse. The retention
+// fields only apply to stored logs.
+type uploadRequest struct {
+	Content string `form:"content" json:"content" binding:"required"`
+	// ExpiresIn asks for the log to expire after that many seconds instead
+	// of storage.time_to_live
+	ExpiresIn int64 `form:"expires_in" json:"expires_in" binding:"min=0"`
+	// MaxViews asks for the log to be deleted after that many views
+	MaxViews int `form:"max_views" json:"max_views" binding:"min=0"`
+	// Password protects the log; readers need it or an access token
+	Password string `form:"password" json:"password" binding:"max=1024"`
+}
+
+// bindContent reads the posted log and runs its content through the
+// filters. If that fails, it answers the request and returns false.
+func (h *Handler) bindContent(c *gin.Context) (uploadRequest, bool) {
+	var req uploadRequest
+	if err := c.ShouldBind(&req); err != nil {
+		bindError(c, err)
+		return req, false
+	}
+
+	content, err := h.filterContent(req.Content)
+	if err != nil {
+		log.Printf("[API] Error filtering log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to filter log"})
+		return req, false
+	}
+	req.Content = content
+	return req, true
+}
+
+// bindError answers a request whose content could not be bound.
+func bindError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit)})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// filters are applied to every uploaded log, in order. A limit of zero
+// disables its filter.
+func (h *Handler) filters() []filter.Filter {
+	var filters []filter.Filter
+	if h.cfg.Storage.MaxLength > 0 {
+		filters = append(filters, &filter.LengthFilter{MaxLength: h.cfg.Storage.MaxLength})
+	}
+	if h.cfg.Storage.MaxLines > 0 {
+		filters = append(filters, &filter.LinesFilter{MaxLines: h.cfg.Storage.MaxLines})
+	}
+	return filters
+}
+
+// filterContent runs content through the filters.
+func (h *Handler) filterContent(content string) (string, error) {
+	for _, f := range h.filters() {
+		var err error
+		if content, err = f.Filter(content); err != nil {
+			return "", fmt.Errorf("%s filter: %w", f.Name(), err)
+		}
+	}
+	return content, nil
+}
+
+// GetLimits tells clients how large a log may be before it is cut, so they
+// can check or shorten it before uploading.
+func (h *Handler) GetLimits(c *gin.Context) {
+	names := []string{}
+	for _, f := range h.filters() {
+		names = append(names, f.Name())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"storageTime":       h.cfg.Storage.TTL,
+		"maxLength":         h.cfg.Storage.MaxLength,
+		"maxLines":          h.cfg.Storage.MaxLines,
+		"maxBodySize":       maxBodySize(h.cfg),
+		"maxBundleBodySize": maxBundleBodySize(h.cfg),
+		"maxBundleFiles":    maxBundleFiles,
+		"encodings":         contentEncodings,
+		"filters":           names,
+		"minExpiresIn":      minExpiresIn(h.cfg),
+		"maxExpiresIn":      maxExpiresIn(h.cfg),
+		"maxViews":          h.cfg.Storage.MaxViews,
+	})
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mclogs-go/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestDecodeBodyLargeForm posts a form above net/http's default 10 MiB
+// form limit, which max_body_size must replace.
+func TestDecodeBodyLargeForm(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	content := strings.Repeat("[12:00:00] [Server thread/INFO]: Done\n", (11<<20)/38)
+	form := url.Values{"content": {content}}.Encode()
+
+	r := gin.New()
+	r.Use(DecodeBody(&config.Config{}))
+	r.POST("/", func(c *gin.Context) {
+		c.String(http.StatusOK, strconv.Itoa(len(c.PostForm("content"))))
+	})
+
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	zw.Write([]byte(form))
+	zw.Close()
+
+	for _, encoding := range []string{"", "gzip"} {
+		body := []byte(form)
+		if encoding != "" {
+			body = gzipped.Bytes()
+		}
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if encoding != "" {
+			req.Header.Set("Content-Encoding", encoding)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if got := w.Body.String(); w.Code != http.StatusOK || got != strconv.Itoa(len(content)) {
+			t.Errorf("encoding %q: status %d, content of %s bytes, want %d", encoding, w.Code, got, len(content))
+		}
+	}
+}
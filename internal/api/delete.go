@@ -0,0 +1,77 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newDeleteToken returns a secret for the uploader and the hash to store
+// with the log.
+func newDeleteToken() (token, hash string) {
+	b := make([]byte, 32)
+	rand.Read(b)
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashDeleteToken(token)
+}
+
+func hashDeleteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkDeleteToken compares a token with a stored hash in constant time. A
+// log stored without a hash accepts no token.
+func checkDeleteToken(token, hash string) bool {
+	if token == "" || hash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashDeleteToken(token)), []byte(hash)) == 1
+}
+
+// DeleteLog deletes a log for whoever holds the token returned when it was
+// uploaded, sent as "Authorization: Bearer <token>".
+func (h *Handler) DeleteLog(c *gin.Context) {
+	id := c.Param("id")
+
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Delete token required"})
+		return
+	}
+
+	logData, err := h.storage.Peek(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("[API] Error retrieving log %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve log"})
+		return
+	}
+	if logData == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Log not found"})
+		return
+	}
+
+	if !checkDeleteToken(strings.TrimSpace(token), logData.DeleteTokenHash) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid delete token"})
+		return
+	}
+
+	if err := h.storage.Delete(c.Request.Context(), id); err != nil {
+		log.Printf("[API] Error deleting log %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete log"})
+		return
+	}
+	h.purgeInsights(c.Request.Context(), id)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Log deleted successfully",
+	})
+}
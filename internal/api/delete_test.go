@@ -0,0 +1,27 @@
+package api
+
+import "testing"
+
+func TestCheckDeleteToken(t *testing.T) {
+	token, hash := newDeleteToken()
+	other, _ := newDeleteToken()
+
+	tests := []struct {
+		name  string
+		token string
+		hash  string
+		want  bool
+	}{
+		{"right token", token, hash, true},
+		{"wrong token", other, hash, false},
+		{"empty token", "", hash, false},
+		{"log stored without a hash", token, "", false},
+		{"empty token and hash", "", "", false},
+		{"token sent as its hash", hash, hash, false},
+	}
+	for _, tt := range tests {
+		if got := checkDeleteToken(tt.token, tt.hash); got != tt.want {
+			t.Errorf("%s: checkDeleteToken = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"log"
 	"mclogs-go/internal/cache"
 	"mclogs-go/internal/config"
-	"mclogs-go/internal/filter"
+	"mclogs-go/internal/models"
 	"mclogs-go/internal/parser"
 	"mclogs-go/internal/storage"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
 )
 
 type Handler struct {
@@ -17,55 +21,86 @@ type Handler struct {
 	cache   cache.Cache
 	parser  *parser.Parser
 	cfg     *config.Config
+	// collapses concurrent analyses of the same log
+	flight singleflight.Group
+	// signs access tokens of password-protected logs
+	accessKey []byte
+	attempts  *attemptLimiter
+	// proxies whose X-Forwarded headers are used, if urls.trust_proxy is on
+	proxies []*net.IPNet
 }
 
 func NewHandler(s storage.Storage, c cache.Cache, p *parser.Parser, cfg *config.Config) *Handler {
 	return &Handler{
-		storage: s,
-		cache:   c,
-		parser:  p,
-		cfg:     cfg,
+		storage:   s,
+		cache:     c,
+		parser:    p,
+		cfg:       cfg,
+		accessKey: newAccessKey(cfg.Server.Secret),
+		attempts:  newAttemptLimiter(),
+		proxies:   parseProxies(cfg.URLs.TrustedProxies),
 	}
 }
 
 func (h *Handler) CreateLog(c *gin.Context) {
-	var req struct {
-		Content string `form:"content" json:"content" binding:"required"`
+	req, ok := h.bindContent(c)
+	if !ok {
+		return
 	}
 
-	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// Store log, keeping only a hash of the delete token
+	token, tokenHash := newDeleteToken()
+	logData := &models.Log{
+		Content:         req.Content,
+		DeleteTokenHash: tokenHash,
+		MaxViews:        h.maxViews(req.MaxViews),
+	}
+	if req.Password != "" {
+		logData.PasswordHash = hashPassword(req.Password)
+	}
+	if d := h.expiresIn(req.ExpiresIn); d > 0 {
+		logData.ExpiresAt = time.Now().Add(d)
+	}
+	id, err := h.storage.Put(c.Request.Context(), logData)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store log"})
 		return
 	}
 
-	content := req.Content
-
-	// Apply filters
-	lenFilter := &filter.LengthFilter{MaxLength: h.cfg.Storage.MaxLength}
-	content, _ = lenFilter.Filter(content)
-
-	lineFilter := &filter.LinesFilter{MaxLines: h.cfg.Storage.MaxLines}
-	content, _ = lineFilter.Filter(content)
+	resp := h.logURLs(c, id)
+	resp["success"] = true
+	resp["id"] = id
+	resp["delete_token"] = token
+	if !logData.ExpiresAt.IsZero() {
+		resp["expires_at"] = logData.ExpiresAt
+	}
+	if logData.MaxViews > 0 {
+		resp["max_views"] = logData.MaxViews
+	}
+	if logData.PasswordHash != "" {
+		resp["protected"] = true
+	}
+	c.JSON(http.StatusOK, resp)
+}
 
-	// Store log
-	id, err := h.storage.Put(c.Request.Context(), content)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store log"})
+// Analyse returns the insights of a posted log without storing it.
+func (h *Handler) Analyse(c *gin.Context) {
+	req, ok := h.bindContent(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"id":      id,
-		"url":     "https://mclogs.lemwood.icu/" + id,
-	})
+	c.JSON(http.StatusOK, h.parse(req.Content, h.locale(c)))
 }
 
+// GetLog returns the insights of a log. Like GetAIAnalysis it peeks, so
+// it does not count towards max_views even though the insights quote the
+// log.
 func (h *Handler) GetLog(c *gin.Context) {
 	id := c.Param("id")
 	log.Printf("[API] GetLog request for ID: %s", id)
 
-	logData, err := h.storage.Get(c.Request.Context(), id)
+	logData, err := h.storage.Peek(c.Request.Context(), id)
 	if err != nil {
 		log.Printf("[API] Error retrieving log %s: %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve log"})
@@ -77,10 +112,11 @@ func (h *Handler) GetLog(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Log not found"})
 		return
 	}
+	if !h.authorize(c, logData) {
+		return
+	}
 
-	// In a real app, you might want to cache the analysis result
-	analysis := h.parser.Parse(logData.Content)
-	analysis.ID = logData.ID
+	analysis := h.analyze(c.Request.Context(), logData, h.locale(c))
 
 	c.JSON(http.StatusOK, analysis)
 }
@@ -88,7 +124,7 @@ func (h *Handler) GetLog(c *gin.Context) {
 func (h *Handler) GetAIAnalysis(c *gin.Context) {
 	id := c.Param("id")
 
-	logData, err := h.storage.Get(c.Request.Context(), id)
+	logData, err := h.storage.Peek(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve log"})
 		return
@@ -98,16 +134,20 @@ func (h *Handler) GetAIAnalysis(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Log not found"})
 		return
 	}
+	if !h.authorize(c, logData) {
+		return
+	}
 
-	// Currently, we use the rule engine for analysis. 
+	// Currently, we use the rule engine for analysis.
 	// In the future, this can be integrated with actual AI models.
-	analysis := h.parser.Parse(logData.Content)
-	
+	analysis := h.analyze(c.Request.Context(), logData, h.locale(c))
+	text := markdownTextFor(c, analysis.Locale)
+
 	// Format the analysis result into a markdown string for the frontend's AI display
 	var markdown string
-	markdown += "## 日志分析摘要\n\n"
+	markdown += "## " + text.Summary + "\n\n"
 	if len(analysis.Information) > 0 {
-		markdown += "### 基本信息\n"
+		markdown += "### " + text.Information + "\n"
 		for _, info := range analysis.Information {
 			markdown += "- **" + info.Label + "**: " + info.Value + "\n"
 		}
@@ -115,7 +155,7 @@ func (h *Handler) GetAIAnalysis(c *gin.Context) {
 	}
 
 	if len(analysis.Problems) > 0 {
-		markdown += "### 发现的问题\n"
+		markdown += "### " + text.Problems + "\n"
 		for _, prob := range analysis.Problems {
 			severityEmoji := "⚠️"
 			if prob.Severity == "error" || prob.Severity == "critical" {
@@ -123,29 +163,48 @@ func (h *Handler) GetAIAnalysis(c *gin.Context) {
 			}
 			markdown += "#### " + severityEmoji + " " + prob.Message + "\n"
 			if len(prob.Solutions) > 0 {
-				markdown += "**建议方案:**\n"
+				markdown += "**" + text.Solutions + "**\n"
 				for _, sol := range prob.Solutions {
-					markdown += "- " + sol.Message + "\n"
+					markdown += "- " + sol.Message
+					if a := sol.Action; a != nil && a.URL != "" {
+						label := a.Label
+						if label == "" {
+							label = a.URL
+						}
+						markdown += " [" + label + "](" + a.URL + ")"
+					}
+					markdown += "\n"
 				}
 			}
 			markdown += "\n"
 		}
 	} else {
-		markdown += "✅ 未发现明显的问题。\n"
+		markdown += "✅ " + text.NoProblems + "\n"
 	}
 
 	// Format to match the frontend's expected AI analysis response
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
+		"success":  true,
 		"analysis": markdown,
 	})
 }
 
+// GetRawLog returns the content of a log. Only this read counts towards
+// max_views; insights are peeked so clients can load them alongside.
 func (h *Handler) GetRawLog(c *gin.Context) {
 	id := c.Param("id")
 	log.Printf("[API] GetRawLog request for ID: %s", id)
 
-	logData, err := h.storage.Get(c.Request.Context(), id)
+	logData, err := h.storage.Peek(c.Request.Context(), id)
+	if err == nil && logData != nil {
+		if !h.authorize(c, logData) {
+			return
+		}
+		// only authorized reads count as views
+		if logData.MaxViews > 0 {
+			logData, err = h.storage.Get(c.Request.Context(), id)
+		}
+	}
 	if err != nil {
 		log.Printf("[API] Error retrieving raw log %s: %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve log"})
@@ -158,5 +217,13 @@ func (h *Handler) GetRawLog(c *gin.Context) {
 		return
 	}
 
+	if logData.MaxViews > 0 {
+		c.Header("X-Views-Remaining", strconv.Itoa(logData.MaxViews-logData.Views))
+		if logData.Views >= logData.MaxViews {
+			// that was the last view, which deleted the log
+			h.purgeInsights(c.Request.Context(), id)
+		}
+	}
+
 	c.String(http.StatusOK, logData.Content)
 }
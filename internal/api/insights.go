@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"mclogs-go/internal/cache"
+	"mclogs-go/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultInsightsTTL is how long analysis results are cached when the config
+// does not say.
+const defaultInsightsTTL = 24 * time.Hour
+
+// insightsKey is the cache key of a log's analysis. It contains the rule set
+// version, so changing the patterns file leaves old entries unreachable.
+func insightsKey(version, id, locale string) string {
+	return "insights:" + version + ":" + id + ":" + locale
+}
+
+// analyze returns the analysis of a log in locale, from the cache when the
+// current rule set already analyzed it. Concurrent misses for the same log
+// and locale share one parse. The result may be shared and must not be
+// modified.
+func (h *Handler) analyze(ctx context.Context, logData *models.Log, locale string) *models.AnalysisResult {
+	key := insightsKey(h.parser.Version(), logData.ID, locale)
+
+	if cached, err := h.cache.Get(ctx, key); err != nil {
+		log.Printf("[Cache] Error reading %s: %v", key, err)
+	} else if cached != "" {
+		var analysis models.AnalysisResult
+		if err := json.Unmarshal([]byte(cached), &analysis); err == nil {
+			return &analysis
+		}
+		log.Printf("[Cache] Discarding unreadable entry %s", key)
+	}
+
+	v, _, _ := h.flight.Do(key, func() (any, error) {
+		analysis := h.parse(logData.Content, locale)
+		analysis.ID = logData.ID
+		// the entry outlives the request that happened to compute it
+		h.storeInsights(context.WithoutCancel(ctx), key, analysis, logData.ExpiresAt)
+		return analysis, nil
+	})
+	return v.(*models.AnalysisResult)
+}
+
+// parse analyzes a log in one pass with the streaming engine. Reading from
+// a string cannot fail, so neither can it.
+func (h *Handler) parse(content, locale string) *models.AnalysisResult {
+	analysis, _ := h.parser.ParseReader(strings.NewReader(content), locale)
+	return analysis
+}
+
+// storeInsights caches an analysis, never beyond the expiry of its log.
+func (h *Handler) storeInsights(ctx context.Context, key string, analysis *models.AnalysisResult, expiresAt time.Time) {
+	ttl := defaultInsightsTTL
+	if h.cfg.Cache.TTL > 0 {
+		ttl = time.Duration(h.cfg.Cache.TTL) * time.Second
+	}
+	if !expiresAt.IsZero() {
+		ttl = min(ttl, time.Until(expiresAt))
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(analysis)
+	if err != nil {
+		log.Printf("[Cache] Error encoding %s: %v", key, err)
+		return
+	}
+	if err := h.cache.Set(ctx, key, string(data), ttl); err != nil {
+		log.Printf("[Cache] Error writing %s: %v", key, err)
+	}
+}
+
+// purgeInsights drops the cached analyses of a log in every locale.
+func (h *Handler) purgeInsights(ctx context.Context, id string) {
+	version := h.parser.Version()
+	for _, locale := range h.parser.Locales() {
+		key := insightsKey(version, id, locale)
+		if err := h.cache.Delete(ctx, key); err != nil {
+			log.Printf("[Cache] Error deleting %s: %v", key, err)
+		}
+	}
+}
+
+// GetCacheStats reports the hit ratio of each cache tier, for drivers that
+// count them.
+func (h *Handler) GetCacheStats(c *gin.Context) {
+	tiers := map[string]cache.TierStats{}
+	if r, ok := h.cache.(cache.StatsReporter); ok {
+		tiers = r.Stats()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": h.cfg.Cache.Enabled,
+		"driver":  h.cfg.Cache.Driver,
+		"tiers":   tiers,
+	})
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"mclogs-go/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// markdownText holds the headings of the AI analysis markdown.
+type markdownText struct {
+	Summary     string
+	Information string
+	Problems    string
+	Solutions   string
+	NoProblems  string
+}
+
+// markdownLocales lists the languages of markdownTexts in matching order.
+var markdownLocales = []string{"zh-CN", "zh-TW", "en"}
+
+var markdownTexts = map[string]markdownText{
+	"zh-CN": {
+		Summary:     "日志分析摘要",
+		Information: "基本信息",
+		Problems:    "发现的问题",
+		Solutions:   "建议方案:",
+		NoProblems:  "未发现明显的问题。",
+	},
+	"zh-TW": {
+		Summary:     "日誌分析摘要",
+		Information: "基本資訊",
+		Problems:    "發現的問題",
+		Solutions:   "建議方案:",
+		NoProblems:  "未發現明顯的問題。",
+	},
+	"en": {
+		Summary:     "Log Analysis Summary",
+		Information: "Basic Information",
+		Problems:    "Problems Found",
+		Solutions:   "Suggested solutions:",
+		NoProblems:  "No obvious problems were found.",
+	},
+}
+
+// requestLocales returns the locales the client asked for, most preferred
+// first. A ?lang= parameter overrides the Accept-Language header.
+func requestLocales(c *gin.Context) []string {
+	if lang := c.Query("lang"); lang != "" {
+		return []string{lang}
+	}
+	return parseAcceptLanguage(c.GetHeader("Accept-Language"))
+}
+
+// parseAcceptLanguage orders the tags of an Accept-Language header by
+// quality, dropping "*" and tags with q=0.
+func parseAcceptLanguage(header string) []string {
+	type tag struct {
+		locale  string
+		quality float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		locale, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		locale = strings.TrimSpace(locale)
+		if locale == "" || locale == "*" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = v
+			}
+		}
+		if quality > 0 {
+			tags = append(tags, tag{locale, quality})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].quality > tags[j].quality })
+
+	locales := make([]string, len(tags))
+	for i, t := range tags {
+		locales[i] = t.locale
+	}
+	return locales
+}
+
+// locale picks the rule locale for the request, the fallback locale if none
+// of the requested ones is available.
+func (h *Handler) locale(c *gin.Context) string {
+	locales := h.parser.Locales()
+	if locale := models.MatchLocale(requestLocales(c), locales); locale != "" {
+		return locale
+	}
+	return locales[0]
+}
+
+// markdownTextFor returns the markdown headings for the request, using the
+// analysis locale when none of the requested languages is available.
+func markdownTextFor(c *gin.Context, analysisLocale string) markdownText {
+	locale := models.MatchLocale(requestLocales(c), markdownLocales)
+	if locale == "" {
+		locale = models.MatchLocale([]string{analysisLocale}, markdownLocales)
+	}
+	if locale == "" {
+		locale = "en"
+	}
+	return markdownTexts[locale]
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"mclogs-go/internal/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id parameters for log passwords, as recommended by OWASP.
+const (
+	argonTime    = 2
+	argonMemory  = 19 * 1024
+	argonThreads = 1
+	argonKeyLen  = 32
+)
+
+// accessTokenTTL is how long a token from the unlock endpoint is valid.
+const accessTokenTTL = 15 * time.Minute
+
+// maxPasswordAttempts passwords can be tried per log and client IP within
+// passwordAttemptWindow, and maxLogPasswordAttempts per log from all
+// clients together; a correct one resets both counts. The per-client limit
+// slows down guessing from one address, and the per-log one guessing from
+// many. Someone with many addresses can still use up the per-log limit and
+// lock the owner out until the window ends; the client IP only comes from
+// X-Forwarded-For when a trusted proxy set it, so it cannot be made up.
+const (
+	maxPasswordAttempts    = 5
+	maxLogPasswordAttempts = 100
+	passwordAttemptWindow  = 15 * time.Minute
+)
+
+// hashPassword returns the argon2id hash of password in the PHC string
+// format.
+func hashPassword(password string) string {
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argonMemory, argonTime, argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+// checkPassword verifies password against a hash from hashPassword, using
+// the parameters stored in the hash.
+func checkPassword(password, encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" || parts[2] != fmt.Sprintf("v=%d", argon2.Version) {
+		return false
+	}
+	var memory, iterations uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false
+	}
+	other := argon2.IDKey([]byte(password), salt, iterations, memory, threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+// newAccessKey returns the key access tokens are signed with. Without a
+// configured secret a random key is used, so tokens only work on this
+// instance until it restarts.
+func newAccessKey(secret string) []byte {
+	if secret != "" {
+		return []byte(secret)
+	}
+	log.Printf("[API] server.secret is not set, access tokens will not survive a restart")
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// accessToken grants access to a password-protected log until expires. It
+// is the expiry in Unix seconds and an HMAC over it and the log ID.
+func (h *Handler) accessToken(id string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + h.signAccess(id, exp)
+}
+
+func (h *Handler) signAccess(id, exp string) string {
+	mac := hmac.New(sha256.New, h.accessKey)
+	mac.Write([]byte(id + "\x00" + exp))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (h *Handler) checkAccessToken(id, token string) bool {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(h.signAccess(id, exp)))
+}
+
+// authorize lets a request read a log. A password-protected log needs an
+// access token in the X-Access-Token header or its password in the
+// X-Log-Password header. Otherwise it answers the request and returns
+// false.
+func (h *Handler) authorize(c *gin.Context, logData *models.Log) bool {
+	if logData.PasswordHash == "" {
+		return true
+	}
+	c.Header("Cache-Control", "private, no-store")
+
+	if token := c.GetHeader("X-Access-Token"); token != "" && h.checkAccessToken(logData.ID, token) {
+		return true
+	}
+	if password := c.GetHeader("X-Log-Password"); password != "" {
+		return h.unlock(c, logData, password)
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Password required", "password_required": true})
+	return false
+}
+
+// unlock checks a password for a log, counting the attempt. If it is wrong
+// or the log had too many attempts, it answers the request and returns
+// false.
+func (h *Handler) unlock(c *gin.Context, logData *models.Log, password string) bool {
+	clientKey := "client:" + logData.ID + "\x00" + h.clientIP(c)
+	logKey := "log:" + logData.ID
+	wait := h.attempts.take(clientKey, maxPasswordAttempts)
+	if wait == 0 {
+		wait = h.attempts.take(logKey, maxLogPasswordAttempts)
+	}
+	if wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many password attempts"})
+		return false
+	}
+	if !checkPassword(password, logData.PasswordHash) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid password", "password_required": true})
+		return false
+	}
+	h.attempts.reset(clientKey)
+	h.attempts.reset(logKey)
+	return true
+}
+
+// UnlockLog exchanges the password of a log for a short-lived access token,
+// so clients don't have to send the password with every request.
+func (h *Handler) UnlockLog(c *gin.Context) {
+	id := c.Param("id")
+
+	var req struct {
+		Password string `form:"password" json:"password" binding:"required"`
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	logData, err := h.storage.Peek(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("[API] Error retrieving log %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve log"})
+		return
+	}
+	if logData == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Log not found"})
+		return
+	}
+	if logData.PasswordHash == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Log is not password protected"})
+		return
+	}
+	if !h.unlock(c, logData, req.Password) {
+		return
+	}
+
+	expires := time.Now().Add(accessTokenTTL)
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"token":      h.accessToken(id, expires),
+		"expires_at": expires,
+	})
+}
+
+// attemptLimiter counts password attempts per key. Attempts are counted
+// before the password is checked, so concurrent guesses can't get past the
+// limit. The counts are kept in memory, so each instance limits on its own.
+type attemptLimiter struct {
+	mu     sync.Mutex
+	counts map[string]*attemptCount
+}
+
+type attemptCount struct {
+	n     int
+	since time.Time
+}
+
+// attemptLimiterSweep is how many keys are tracked before stale counts are
+// dropped.
+const attemptLimiterSweep = 10000
+
+func newAttemptLimiter() *attemptLimiter {
+	return &attemptLimiter{counts: map[string]*attemptCount{}}
+}
+
+// take counts an attempt for key. If limit attempts were already made in
+// the window, it returns how long until the next one is allowed instead.
+func (l *attemptLimiter) take(key string, limit int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if len(l.counts) >= attemptLimiterSweep {
+		for k, a := range l.counts {
+			if now.Sub(a.since) >= passwordAttemptWindow {
+				delete(l.counts, k)
+			}
+		}
+	}
+
+	a := l.counts[key]
+	if a == nil || now.Sub(a.since) >= passwordAttemptWindow {
+		l.counts[key] = &attemptCount{n: 1, since: now}
+		return 0
+	}
+	if a.n >= limit {
+		return passwordAttemptWindow - now.Sub(a.since)
+	}
+	a.n++
+	return 0
+}
+
+func (l *attemptLimiter) reset(key string) {
+	l.mu.Lock()
+	delete(l.counts, key)
+	l.mu.Unlock()
+}
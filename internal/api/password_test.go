@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"mclogs-go/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckPassword(t *testing.T) {
+	hash := hashPassword("hunter2")
+	parts := strings.Split(hash, "$")
+	tamper := func(i int, value string) string {
+		p := append([]string(nil), parts...)
+		p[i] = value
+		return strings.Join(p, "$")
+	}
+	flip := func(s string) string {
+		if s[0] == 'A' {
+			return "B" + s[1:]
+		}
+		return "A" + s[1:]
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+	}{
+		{"right password", "hunter2", hash, true},
+		{"wrong password", "hunter3", hash, false},
+		{"empty password", "", hash, false},
+		{"tampered key", "hunter2", tamper(5, flip(parts[5])), false},
+		{"tampered salt", "hunter2", tamper(4, flip(parts[4])), false},
+		{"tampered parameters", "hunter2", tamper(3, "m=19456,t=1,p=1"), false},
+		{"other algorithm", "hunter2", tamper(1, "argon2i"), false},
+		{"bad base64", "hunter2", tamper(5, "!!!"), false},
+		{"truncated", "hunter2", strings.Join(parts[:4], "$"), false},
+		{"empty hash", "hunter2", "", false},
+	}
+	for _, tt := range tests {
+		if got := checkPassword(tt.password, tt.hash); got != tt.want {
+			t.Errorf("%s: checkPassword = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if hashPassword("hunter2") == hash {
+		t.Error("two hashes of a password are equal, the salt is not random")
+	}
+}
+
+func TestCheckAccessToken(t *testing.T) {
+	h := &Handler{accessKey: []byte("key")}
+	other := &Handler{accessKey: []byte("other key")}
+	valid := h.accessToken("abc", time.Now().Add(time.Minute))
+	exp, sig, _ := strings.Cut(valid, ".")
+
+	tests := []struct {
+		name  string
+		id    string
+		token string
+		want  bool
+	}{
+		{"valid", "abc", valid, true},
+		{"expired", "abc", h.accessToken("abc", time.Now().Add(-time.Second)), false},
+		{"different id", "abd", valid, false},
+		{"bad signature", "abc", exp + "." + sig[:len(sig)-1] + "x", false},
+		{"signed with another key", "abc", other.accessToken("abc", time.Now().Add(time.Minute)), false},
+		{"extended expiry", "abc", "9999999999." + sig, false},
+		{"no signature", "abc", exp, false},
+		{"not a number", "abc", "soon." + sig, false},
+		{"empty", "abc", "", false},
+	}
+	for _, tt := range tests {
+		if got := h.checkAccessToken(tt.id, tt.token); got != tt.want {
+			t.Errorf("%s: checkAccessToken = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAttemptLimiter(t *testing.T) {
+	l := newAttemptLimiter()
+
+	for i := range 3 {
+		if wait := l.take("a", 3); wait != 0 {
+			t.Fatalf("attempt %d: wait %v, want none", i+1, wait)
+		}
+	}
+	if wait := l.take("a", 3); wait <= 0 || wait > passwordAttemptWindow {
+		t.Errorf("attempt over the limit: wait %v, want up to %v", wait, passwordAttemptWindow)
+	}
+	if wait := l.take("b", 3); wait != 0 {
+		t.Errorf("other key: wait %v, want none", wait)
+	}
+
+	// the count starts over once the window has passed
+	l.counts["a"].since = time.Now().Add(-passwordAttemptWindow)
+	if wait := l.take("a", 3); wait != 0 || l.counts["a"].n != 1 {
+		t.Errorf("after the window: wait %v and %d attempts, want none and 1", wait, l.counts["a"].n)
+	}
+
+	l.take("a", 3)
+	l.take("a", 3)
+	l.reset("a")
+	if wait := l.take("a", 3); wait != 0 {
+		t.Errorf("after reset: wait %v, want none", wait)
+	}
+}
+
+// TestUnlockPerClient checks that one client guessing passwords doesn't
+// lock out others, and that access tokens are only taken from the header.
+func TestUnlockPerClient(t *testing.T) {
+	r := newTestServer(t, newMemStore(), &config.Config{})
+	do := func(method, target, body, remoteAddr string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/1/log", `{"content": "secret log", "password": "hunter2"}`, "192.0.2.1:1000", nil)
+	var created struct{ ID string }
+	if err := json.Unmarshal(w.Body.Bytes(), &created); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("upload: status %d, %s", w.Code, w.Body)
+	}
+	unlock := "/1/unlock/" + created.ID
+
+	for i := range maxPasswordAttempts {
+		if w := do(http.MethodPost, unlock, `{"password": "guess"}`, "198.51.100.7:2000", nil); w.Code != http.StatusForbidden {
+			t.Fatalf("guess %d: status %d, want 403", i+1, w.Code)
+		}
+	}
+	if w := do(http.MethodPost, unlock, `{"password": "hunter2"}`, "198.51.100.7:2000", nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("guessing client: status %d, want 429", w.Code)
+	}
+	// without a trusted proxy the header cannot buy new attempts
+	if w := do(http.MethodPost, unlock, `{"password": "guess"}`, "198.51.100.7:2000", http.Header{"X-Forwarded-For": {"203.0.113.9"}}); w.Code != http.StatusTooManyRequests {
+		t.Errorf("guessing client with X-Forwarded-For: status %d, want 429", w.Code)
+	}
+
+	w = do(http.MethodPost, unlock, `{"password": "hunter2"}`, "192.0.2.1:1000", nil)
+	var unlocked struct{ Token string }
+	if err := json.Unmarshal(w.Body.Bytes(), &unlocked); w.Code != http.StatusOK || err != nil || unlocked.Token == "" {
+		t.Fatalf("owner: status %d, %s", w.Code, w.Body)
+	}
+
+	raw := "/1/raw/" + created.ID
+	if w := do(http.MethodGet, raw+"?token="+unlocked.Token, "", "192.0.2.1:1000", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("token in the query: status %d, want 401", w.Code)
+	}
+	if w := do(http.MethodGet, raw, "", "192.0.2.1:1000", http.Header{"X-Access-Token": {unlocked.Token}}); w.Code != http.StatusOK || w.Body.String() != "secret log" {
+		t.Errorf("token in the header: status %d, %q", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"mclogs-go/internal/config"
+	"time"
+)
+
+// defaultMinExpiresIn is the shortest expiry an upload can ask for when
+// storage.min_time_to_live is not set.
+const defaultMinExpiresIn = 60
+
+// minExpiresIn is the shortest expires_in accepted, in seconds.
+func minExpiresIn(cfg *config.Config) int64 {
+	if cfg.Storage.MinTTL > 0 {
+		return cfg.Storage.MinTTL
+	}
+	return defaultMinExpiresIn
+}
+
+// maxExpiresIn is the longest expires_in accepted, in seconds. Zero means
+// there is no bound.
+func maxExpiresIn(cfg *config.Config) int64 {
+	if cfg.Storage.MaxTTL > 0 {
+		return cfg.Storage.MaxTTL
+	}
+	return cfg.Storage.TTL
+}
+
+// expiresIn clamps the requested lifetime of an upload to the configured
+// bounds. Zero leaves the expiry to the storage.
+func (h *Handler) expiresIn(requested int64) time.Duration {
+	if requested <= 0 {
+		return 0
+	}
+	seconds := max(requested, minExpiresIn(h.cfg))
+	if limit := maxExpiresIn(h.cfg); limit > 0 {
+		seconds = min(seconds, limit)
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// maxViews clamps the requested view limit of an upload to
+// storage.max_views. Zero means the log can be viewed until it expires.
+func (h *Handler) maxViews(requested int) int {
+	if requested <= 0 {
+		return 0
+	}
+	if limit := h.cfg.Storage.MaxViews; limit > 0 {
+		return min(requested, limit)
+	}
+	return requested
+}
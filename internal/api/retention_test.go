@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"mclogs-go/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpiresIn(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       int64
+		min, max  int64
+		requested int64
+		want      time.Duration
+	}{
+		{"not requested", 86400, 0, 0, 0, 0},
+		{"negative", 86400, 0, 0, -5, 0},
+		{"below the default minimum", 86400, 0, 0, 10, time.Minute},
+		{"below the configured minimum", 86400, 300, 0, 120, 5 * time.Minute},
+		{"within bounds", 86400, 0, 0, 3600, time.Hour},
+		{"above time_to_live", 86400, 0, 0, 1e9, 24 * time.Hour},
+		{"above max_time_to_live", 86400, 0, 7 * 86400, 1e9, 7 * 24 * time.Hour},
+		{"no upper bound", 0, 0, 0, 1e9, 1e9 * time.Second},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		cfg.Storage.TTL, cfg.Storage.MinTTL, cfg.Storage.MaxTTL = tt.ttl, tt.min, tt.max
+		h := &Handler{cfg: cfg}
+		if got := h.expiresIn(tt.requested); got != tt.want {
+			t.Errorf("%s: expiresIn(%d) = %v, want %v", tt.name, tt.requested, got, tt.want)
+		}
+	}
+}
+
+func TestMaxViews(t *testing.T) {
+	tests := []struct {
+		limit, requested, want int
+	}{
+		{0, 0, 0},
+		{0, -1, 0},
+		{0, 1000, 1000},
+		{3, 0, 0},
+		{3, 2, 2},
+		{3, 5, 3},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		cfg.Storage.MaxViews = tt.limit
+		h := &Handler{cfg: cfg}
+		if got := h.maxViews(tt.requested); got != tt.want {
+			t.Errorf("storage.max_views %d: maxViews(%d) = %d, want %d", tt.limit, tt.requested, got, tt.want)
+		}
+	}
+}
+
+// TestRawLogMaxViews checks a log with max_views=1 can be read once.
+func TestRawLogMaxViews(t *testing.T) {
+	r := newTestServer(t, newMemStore(), &config.Config{})
+	const content = "[12:00:00] [Server thread/INFO]: Done (1.234s)!"
+
+	req := httptest.NewRequest(http.MethodPost, "/1/log", strings.NewReader(`{"content": "`+content+`", "max_views": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var created struct {
+		ID       string `json:"id"`
+		MaxViews int    `json:"max_views"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("upload: status %d, %s", w.Code, w.Body)
+	}
+	if created.MaxViews != 1 {
+		t.Errorf("upload returned max_views %d, want 1", created.MaxViews)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/1/raw/"+created.ID, nil))
+	if w.Code != http.StatusOK || w.Body.String() != content {
+		t.Fatalf("first read: status %d, %q", w.Code, w.Body)
+	}
+	if got := w.Header().Get("X-Views-Remaining"); got != "0" {
+		t.Errorf("X-Views-Remaining = %q, want 0", got)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/1/raw/"+created.ID, nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("second read: status %d, want 404", w.Code)
+	}
+}
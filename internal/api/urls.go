@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestOrigin is the scheme and host a request was sent to. The
+// X-Forwarded headers are only used from a trusted proxy, since anyone can
+// send them to the API directly.
+func (h *Handler) requestOrigin(c *gin.Context) string {
+	scheme, host := "http", c.Request.Host
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if h.fromProxy(c) {
+		// a chain of proxies appends to the headers; the first is the client's
+		if v := firstHeaderValue(c.GetHeader("X-Forwarded-Proto")); v == "http" || v == "https" {
+			scheme = v
+		}
+		if v := firstHeaderValue(c.GetHeader("X-Forwarded-Host")); v != "" {
+			host = v
+		}
+	}
+	return scheme + "://" + host
+}
+
+// fromProxy reports whether a request came through one of the trusted
+// proxies, when urls.trust_proxy is on.
+func (h *Handler) fromProxy(c *gin.Context) bool {
+	return h.cfg.URLs.TrustProxy && h.isProxy(c.RemoteIP())
+}
+
+// clientIP is the address password attempts are counted by. Behind a
+// trusted proxy it is the last X-Forwarded-For hop that is not one of the
+// proxies; otherwise the connection's address, so clients cannot pick it.
+func (h *Handler) clientIP(c *gin.Context) string {
+	ip := c.RemoteIP()
+	if !h.fromProxy(c) {
+		return ip
+	}
+	hops := strings.Split(c.GetHeader("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !h.isProxy(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+func (h *Handler) isProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range h.proxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseProxies parses addresses and CIDRs, skipping invalid ones; the
+// server refuses to start with those.
+func parseProxies(list []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, s := range list {
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+		if _, n, err := net.ParseCIDR(s); err == nil {
+			nets = append(nets, n)
+		}
+	}
+	return nets
+}
+
+func firstHeaderValue(header string) string {
+	v, _, _ := strings.Cut(header, ",")
+	return strings.TrimSpace(v)
+}
+
+// baseURL is the frontend's public address, without a trailing slash.
+func (h *Handler) baseURL(c *gin.Context) string {
+	if base := h.cfg.URLs.BaseURL; base != "" {
+		return strings.TrimRight(base, "/")
+	}
+	return h.requestOrigin(c)
+}
+
+// apiBaseURL is this API's public address, without a trailing slash.
+func (h *Handler) apiBaseURL(c *gin.Context) string {
+	if base := h.cfg.URLs.APIBaseURL; base != "" {
+		return strings.TrimRight(base, "/")
+	}
+	return h.requestOrigin(c)
+}
+
+// logURLs are the links to a stored log returned when it is uploaded. The
+// frontend routes by hash, so the page link goes through "#/".
+func (h *Handler) logURLs(c *gin.Context, id string) gin.H {
+	api := h.apiBaseURL(c)
+	return gin.H{
+		"url":      h.baseURL(c) + "/#/" + id,
+		"raw":      api + "/1/raw/" + id,
+		"insights": api + "/1/insights/" + id,
+	}
+}
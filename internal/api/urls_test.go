@@ -0,0 +1,78 @@
+package api
+
+import (
+	"mclogs-go/internal/config"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestOrigin(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		remote     string
+		want       string
+	}{
+		{"proxy not trusted", false, "127.0.0.1:1234", "http://api.example"},
+		{"from a trusted proxy", true, "127.0.0.1:1234", "https://logs.example"},
+		{"direct client", true, "203.0.113.7:1234", "http://api.example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{URLs: config.URLsConfig{
+				TrustProxy:     tt.trustProxy,
+				TrustedProxies: []string{"127.0.0.1", "::1"},
+			}}
+			h := NewHandler(newMemStore(), nil, nil, cfg)
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "http://api.example/1/log", nil)
+			c.Request.RemoteAddr = tt.remote
+			c.Request.Header.Set("X-Forwarded-Proto", "https")
+			c.Request.Header.Set("X-Forwarded-Host", "logs.example")
+
+			if got := h.requestOrigin(c); got != tt.want {
+				t.Errorf("requestOrigin = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		remote     string
+		forwarded  string
+		want       string
+	}{
+		{"proxy not trusted", false, "127.0.0.1:1234", "203.0.113.7", "127.0.0.1"},
+		{"direct client", true, "198.51.100.1:1234", "203.0.113.7", "198.51.100.1"},
+		{"from a trusted proxy", true, "127.0.0.1:1234", "203.0.113.7", "203.0.113.7"},
+		{"spoofed first hop", true, "127.0.0.1:1234", "192.0.2.99, 203.0.113.7", "203.0.113.7"},
+		{"chain of proxies", true, "127.0.0.1:1234", "203.0.113.7, 10.0.0.2", "203.0.113.7"},
+		{"no header", true, "127.0.0.1:1234", "", "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{URLs: config.URLsConfig{
+				TrustProxy:     tt.trustProxy,
+				TrustedProxies: []string{"127.0.0.1", "10.0.0.0/8"},
+			}}
+			h := NewHandler(newMemStore(), nil, nil, cfg)
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", "/1/unlock/x", nil)
+			c.Request.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				c.Request.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			if got := h.clientIP(c); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
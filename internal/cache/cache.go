@@ -10,3 +10,23 @@ type Cache interface {
 	Set(ctx context.Context, key string, value string, ttl time.Duration) error
 	Delete(ctx context.Context, key string) error
 }
+
+// TierStats counts the lookups answered by one cache tier.
+type TierStats struct {
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+func newTierStats(hits, misses uint64) TierStats {
+	s := TierStats{Hits: hits, Misses: misses}
+	if total := hits + misses; total > 0 {
+		s.HitRatio = float64(hits) / float64(total)
+	}
+	return s
+}
+
+// StatsReporter is implemented by caches that count their hits, by tier.
+type StatsReporter interface {
+	Stats() map[string]TierStats
+}
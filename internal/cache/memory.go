@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultMemorySize is the size bound of a MemoryCache when the config sets
+// none.
+const DefaultMemorySize = 64 << 20
+
+// MemoryCache keeps entries in process, evicting the least recently used
+// once the keys and values together take more than maxBytes.
+type MemoryCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	// most recently used first
+	order   *list.List
+	entries map[string]*list.Element
+
+	hits, misses uint64
+}
+
+type memoryEntry struct {
+	key     string
+	value   string
+	expires time.Time
+}
+
+func (e *memoryEntry) size() int64 {
+	return int64(len(e.key) + len(e.value))
+}
+
+func NewMemoryCache(maxBytes int64) *MemoryCache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMemorySize
+	}
+	return &MemoryCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return "", nil
+	}
+	entry := el.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(el)
+		c.misses++
+		return "", nil
+	}
+	c.order.MoveToFront(el)
+	c.hits++
+	return entry.value, nil
+}
+
+// Set stores a value; a ttl of zero keeps it until it is evicted. Values
+// larger than the whole cache are not stored.
+func (c *MemoryCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	entry := &memoryEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+	if entry.size() > c.maxBytes {
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	c.size += entry.size()
+	for c.size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	return nil
+}
+
+func (c *MemoryCache) Stats() map[string]TierStats {
+	return map[string]TierStats{"memory": c.tierStats()}
+}
+
+func (c *MemoryCache) tierStats() TierStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return newTierStats(c.hits, c.misses)
+}
+
+func (c *MemoryCache) remove(el *list.Element) {
+	entry := c.order.Remove(el).(*memoryEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size()
+}
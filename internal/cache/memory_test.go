@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	// room for three entries of a one byte key and a nine byte value
+	c := NewMemoryCache(30)
+	value := strings.Repeat("x", 9)
+
+	for _, key := range []string{"a", "b", "c"} {
+		c.Set(ctx, key, value, 0)
+	}
+	c.Get(ctx, "a")
+	c.Set(ctx, "d", value, 0)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if got, _ := c.Get(ctx, key); (got != "") != want {
+			t.Errorf("Get(%q) = %q, want present=%v", key, got, want)
+		}
+	}
+	if c.size != 30 {
+		t.Errorf("size = %d, want 30", c.size)
+	}
+}
+
+func TestMemoryCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(0)
+
+	c.Set(ctx, "short", "v", time.Millisecond)
+	c.Set(ctx, "forever", "v", 0)
+	time.Sleep(5 * time.Millisecond)
+
+	if got, _ := c.Get(ctx, "short"); got != "" {
+		t.Errorf("expired entry returned %q", got)
+	}
+	if got, _ := c.Get(ctx, "forever"); got != "v" {
+		t.Errorf("entry without ttl returned %q", got)
+	}
+	if c.size != int64(len("forever")+1) {
+		t.Errorf("size = %d after expiry", c.size)
+	}
+}
+
+func TestMemoryCacheReplaceAndOversize(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(10)
+
+	c.Set(ctx, "k", "1234", 0)
+	c.Set(ctx, "k", "12", 0)
+	if got, _ := c.Get(ctx, "k"); got != "12" || c.size != 3 {
+		t.Errorf("after replace: %q, size %d", got, c.size)
+	}
+
+	c.Set(ctx, "k", strings.Repeat("x", 20), 0)
+	if got, _ := c.Get(ctx, "k"); got != "" || c.size != 0 {
+		t.Errorf("oversized value stored: %q, size %d", got, c.size)
+	}
+}
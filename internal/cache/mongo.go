@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"mclogs-go/internal/config"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoCacheCollection is the collection the PHP core's MongoCache used.
+const mongoCacheCollection = "cache"
+
+// MongoCache stores entries in MongoDB, in the same shape as the PHP core:
+// {_id: key, data: value, expires: date or null}. A TTL index removes
+// expired entries; since MongoDB only does that about once a minute, Get
+// checks the expiry too.
+type MongoCache struct {
+	client     *mongo.Client
+	collection *mongo.Collection
+}
+
+type mongoCacheEntry struct {
+	Key     string     `bson:"_id"`
+	Data    string     `bson:"data"`
+	Expires *time.Time `bson:"expires"`
+}
+
+func NewMongoCache(cfg *config.Config) (*MongoCache, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.Database.MongoDB.URL))
+	if err != nil {
+		return nil, err
+	}
+
+	coll := client.Database(cfg.Database.MongoDB.DB).Collection(mongoCacheCollection)
+	_, err = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoCache{
+		client:     client,
+		collection: coll,
+	}, nil
+}
+
+func (c *MongoCache) Get(ctx context.Context, key string) (string, error) {
+	var entry mongoCacheEntry
+	err := c.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&entry)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", nil
+		}
+		return "", err
+	}
+	if entry.Expires != nil && time.Now().After(*entry.Expires) {
+		return "", nil
+	}
+	return entry.Data, nil
+}
+
+func (c *MongoCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	entry := mongoCacheEntry{Key: key, Data: value}
+	if ttl > 0 {
+		expires := time.Now().Add(ttl)
+		entry.Expires = &expires
+	}
+	_, err := c.collection.ReplaceOne(ctx, bson.M{"_id": key}, entry, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (c *MongoCache) Delete(ctx context.Context, key string) error {
+	_, err := c.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// NoopCache stores nothing. It stands in when caching is disabled, so
+// callers never have to check for a nil cache.
+type NoopCache struct{}
+
+func (NoopCache) Get(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+func (NoopCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return nil
+}
+
+func (NoopCache) Delete(ctx context.Context, key string) error {
+	return nil
+}
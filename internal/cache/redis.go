@@ -39,3 +39,36 @@ func (c *RedisCache) Set(ctx context.Context, key string, value string, ttl time
 func (c *RedisCache) Delete(ctx context.Context, key string) error {
 	return c.client.Del(ctx, key).Err()
 }
+
+func (c *RedisCache) Publish(ctx context.Context, channel, message string) error {
+	return c.client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe delivers the messages published on channel until ctx is done.
+// The client resubscribes by itself after a lost connection.
+func (c *RedisCache) Subscribe(ctx context.Context, channel string) <-chan string {
+	sub := c.client.Subscribe(ctx, channel)
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case out <- msg.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// invalidationChannel is the Redis pub/sub channel replicas announce changed
+// keys on.
+const invalidationChannel = "mclogs:cache:invalidate"
+
+// DefaultLocalTTL bounds how long a replica serves an entry from its local
+// tier when the config sets no local_ttl. It is also how long a replica can
+// serve a stale entry if it missed an invalidation while disconnected.
+const DefaultLocalTTL = time.Minute
+
+// remoteCache is the tier shared by the replicas, which also carries their
+// invalidations.
+type remoteCache interface {
+	Cache
+	Publish(ctx context.Context, channel, message string) error
+	// Subscribe delivers the messages published on channel until ctx is
+	// done, then closes the returned channel.
+	Subscribe(ctx context.Context, channel string) <-chan string
+}
+
+// TieredCache answers from a small in-process LRU first and from Redis
+// after that. Every Set and Delete is announced over Redis pub/sub so the
+// other replicas drop their local copy.
+type TieredCache struct {
+	local    *MemoryCache
+	remote   remoteCache
+	localTTL time.Duration
+	// tags this replica's announcements, so it ignores its own
+	origin string
+
+	remoteHits, remoteMisses atomic.Uint64
+}
+
+// NewTieredCache puts local in front of remote and listens for
+// invalidations from other replicas until ctx is done.
+func NewTieredCache(ctx context.Context, local *MemoryCache, remote *RedisCache, localTTL time.Duration) *TieredCache {
+	return newTieredCache(ctx, local, remote, localTTL)
+}
+
+func newTieredCache(ctx context.Context, local *MemoryCache, remote remoteCache, localTTL time.Duration) *TieredCache {
+	if localTTL <= 0 {
+		localTTL = DefaultLocalTTL
+	}
+	origin := make([]byte, 8)
+	rand.Read(origin)
+
+	c := &TieredCache{
+		local:    local,
+		remote:   remote,
+		localTTL: localTTL,
+		origin:   hex.EncodeToString(origin),
+	}
+	go c.listen(ctx)
+	return c
+}
+
+func (c *TieredCache) Get(ctx context.Context, key string) (string, error) {
+	if val, _ := c.local.Get(ctx, key); val != "" {
+		return val, nil
+	}
+
+	val, err := c.remote.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if val == "" {
+		c.remoteMisses.Add(1)
+		return "", nil
+	}
+	c.remoteHits.Add(1)
+	c.local.Set(ctx, key, val, c.localTTL)
+	return val, nil
+}
+
+func (c *TieredCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	if err := c.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	localTTL := c.localTTL
+	if ttl > 0 {
+		localTTL = min(localTTL, ttl)
+	}
+	c.local.Set(ctx, key, value, localTTL)
+	c.announce(ctx, key)
+	return nil
+}
+
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	c.local.Delete(ctx, key)
+	if err := c.remote.Delete(ctx, key); err != nil {
+		return err
+	}
+	c.announce(ctx, key)
+	return nil
+}
+
+// Stats reports the local tier and the Redis lookups it missed.
+func (c *TieredCache) Stats() map[string]TierStats {
+	return map[string]TierStats{
+		"local": c.local.tierStats(),
+		"redis": newTierStats(c.remoteHits.Load(), c.remoteMisses.Load()),
+	}
+}
+
+// announce tells the other replicas that key changed.
+func (c *TieredCache) announce(ctx context.Context, key string) {
+	if err := c.remote.Publish(ctx, invalidationChannel, c.origin+" "+key); err != nil {
+		log.Printf("[Cache] Failed to announce invalidation of %s: %v", key, err)
+	}
+}
+
+// listen drops local entries other replicas announce.
+func (c *TieredCache) listen(ctx context.Context) {
+	for payload := range c.remote.Subscribe(ctx, invalidationChannel) {
+		c.invalidate(ctx, payload)
+	}
+}
+
+// invalidate handles one announcement, "<origin> <key>".
+func (c *TieredCache) invalidate(ctx context.Context, payload string) {
+	origin, key, found := strings.Cut(payload, " ")
+	if found && origin != c.origin {
+		c.local.Delete(ctx, key)
+	}
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRemote stands in for Redis: one store and one pub/sub bus shared by
+// every replica given it.
+type fakeRemote struct {
+	*MemoryCache
+
+	mu   sync.Mutex
+	subs map[chan string]bool
+}
+
+func newFakeRemote() *fakeRemote {
+	return &fakeRemote{MemoryCache: NewMemoryCache(0), subs: map[chan string]bool{}}
+}
+
+func (r *fakeRemote) Publish(ctx context.Context, channel, message string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for sub := range r.subs {
+		sub <- message
+	}
+	return nil
+}
+
+func (r *fakeRemote) Subscribe(ctx context.Context, channel string) <-chan string {
+	sub := make(chan string, 16)
+	r.mu.Lock()
+	r.subs[sub] = true
+	r.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		r.mu.Lock()
+		delete(r.subs, sub)
+		close(sub)
+		r.mu.Unlock()
+	}()
+	return sub
+}
+
+func (r *fakeRemote) subscribers() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.subs)
+}
+
+// eventually waits for cond, which another goroutine makes true.
+func eventually(t *testing.T, cond func() bool, format string, args ...any) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf(format, args...)
+		}
+	}
+}
+
+func TestTieredCacheInvalidation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	remote := newFakeRemote()
+	a := newTieredCache(ctx, NewMemoryCache(0), remote, time.Hour)
+	b := newTieredCache(ctx, NewMemoryCache(0), remote, time.Hour)
+	eventually(t, func() bool { return remote.subscribers() == 2 }, "the replicas did not subscribe")
+	cached := func(c *TieredCache, key string) string {
+		v, _ := c.local.Get(ctx, key)
+		return v
+	}
+
+	a.Set(ctx, "k", "v1", 0)
+	if got, _ := b.Get(ctx, "k"); got != "v1" || cached(b, "k") != "v1" {
+		t.Fatalf("b.Get = %q, local %q; want v1 from the remote, kept locally", got, cached(b, "k"))
+	}
+
+	a.Set(ctx, "k", "v2", 0)
+	eventually(t, func() bool { return cached(b, "k") == "" }, "b kept its local copy after a's Set")
+	if got, _ := b.Get(ctx, "k"); got != "v2" {
+		t.Errorf("b.Get = %q after a's Set, want v2", got)
+	}
+
+	a.Delete(ctx, "k")
+	eventually(t, func() bool { return cached(b, "k") == "" }, "b kept its local copy after a's Delete")
+	if got, _ := b.Get(ctx, "k"); got != "" {
+		t.Errorf("b.Get = %q after a's Delete, want nothing", got)
+	}
+
+	// a replica ignores its own announcements
+	a.Set(ctx, "own", "v", 0)
+	a.invalidate(ctx, a.origin+" own")
+	if cached(a, "own") != "v" {
+		t.Error("a dropped its local copy on its own announcement")
+	}
+	a.invalidate(ctx, b.origin+" own")
+	if cached(a, "own") != "" {
+		t.Error("a kept its local copy on another replica's announcement")
+	}
+}
+
+func TestTieredCacheStats(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	remote := newFakeRemote()
+	c := newTieredCache(ctx, NewMemoryCache(0), remote, time.Hour)
+
+	remote.Set(ctx, "k", "v", 0)
+	c.Get(ctx, "k")       // local miss, remote hit
+	c.Get(ctx, "k")       // local hit
+	c.Get(ctx, "missing") // local and remote miss
+
+	stats := c.Stats()
+	if got, want := stats["local"], newTierStats(1, 2); got != want {
+		t.Errorf("local = %+v, want %+v", got, want)
+	}
+	if got, want := stats["redis"], newTierStats(1, 1); got != want {
+		t.Errorf("redis = %+v, want %+v", got, want)
+	}
+}
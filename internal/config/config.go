@@ -13,13 +13,23 @@ type Config struct {
 	Storage  StorageConfig  `mapstructure:"storage"`
 	Cache    CacheConfig    `mapstructure:"cache"`
 	AI       AIConfig       `mapstructure:"ai"`
+	URLs     URLsConfig     `mapstructure:"urls"`
 	Patterns string         `mapstructure:"patterns"`
 }
 
 type ServerConfig struct {
 	Port int    `mapstructure:"port"`
 	Mode string `mapstructure:"mode"`
-	CORS struct {
+	// MaxBodySize limits request bodies in bytes, before and after decoding
+	MaxBodySize int64 `mapstructure:"max_body_size"`
+	// Secret signs access tokens of password-protected logs. Instances
+	// behind one domain need the same secret; when empty a random one is
+	// used on every start.
+	Secret string `mapstructure:"secret"`
+	// AdminToken is the bearer token of the operator endpoints such as
+	// /1/cache/stats; when empty they are disabled.
+	AdminToken string `mapstructure:"admin_token"`
+	CORS       struct {
 		Enabled          bool     `mapstructure:"enabled"`
 		AllowedOrigins   []string `mapstructure:"allowed_origins"`
 		AllowCredentials bool     `mapstructure:"allow_credentials"`
@@ -55,14 +65,21 @@ type RedisConfig struct {
 }
 
 type StorageConfig struct {
-	CurrentID   string           `mapstructure:"current_id"`
-	TTL         int64            `mapstructure:"time_to_live"`
-	MaxLength   int              `mapstructure:"max_length"`
-	MaxLines    int              `mapstructure:"max_lines"`
-	Filesystem  FilesystemConfig `mapstructure:"filesystem"`
-	MongoDB     EnabledConfig    `mapstructure:"mongodb"`
-	Postgres    EnabledConfig    `mapstructure:"postgres"`
-	Redis       EnabledConfig    `mapstructure:"redis"`
+	CurrentID  string           `mapstructure:"current_id"`
+	TTL        int64            `mapstructure:"time_to_live"`
+	MaxLength  int              `mapstructure:"max_length"`
+	MaxLines   int              `mapstructure:"max_lines"`
+	Filesystem FilesystemConfig `mapstructure:"filesystem"`
+	MongoDB    EnabledConfig    `mapstructure:"mongodb"`
+	Postgres   EnabledConfig    `mapstructure:"postgres"`
+	Redis      EnabledConfig    `mapstructure:"redis"`
+	// MinTTL and MaxTTL bound the expires_in an upload can ask for, in
+	// seconds. MaxTTL defaults to TTL.
+	MinTTL int64 `mapstructure:"min_time_to_live"`
+	MaxTTL int64 `mapstructure:"max_time_to_live"`
+	// MaxViews bounds the max_views an upload can ask for; zero leaves it
+	// unbounded
+	MaxViews int `mapstructure:"max_views"`
 }
 
 type FilesystemConfig struct {
@@ -77,6 +94,31 @@ type EnabledConfig struct {
 type CacheConfig struct {
 	Driver  string `mapstructure:"driver"`
 	Enabled bool   `mapstructure:"enabled"`
+	// TTL of cached analysis results in seconds
+	TTL int64 `mapstructure:"time_to_live"`
+	// MaxSize bounds the memory driver and the local tier of the tiered
+	// driver, in bytes
+	MaxSize int64 `mapstructure:"max_size"`
+	// LocalTTL caps how long the tiered driver keeps entries locally, in
+	// seconds
+	LocalTTL int64 `mapstructure:"local_ttl"`
+}
+
+// URLsConfig holds the public addresses used in links handed out by the
+// API. An empty base is derived from the request.
+type URLsConfig struct {
+	// BaseURL is where the frontend is served, e.g. https://mclogs.example.com
+	BaseURL string `mapstructure:"base_url"`
+	// APIBaseURL is where this API is served, e.g. https://api.mclogs.example.com
+	APIBaseURL string `mapstructure:"api_base_url"`
+	// TrustProxy lets X-Forwarded-Host and X-Forwarded-Proto decide the
+	// derived bases and X-Forwarded-For the client IP, for requests from
+	// TrustedProxies only. Turn it on when the API is behind a proxy such
+	// as nginx.conf.example.
+	TrustProxy bool `mapstructure:"trust_proxy"`
+	// TrustedProxies are the addresses or CIDRs of those proxies; it
+	// defaults to the loopback addresses.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type AIConfig struct {
@@ -87,6 +129,7 @@ func LoadConfig(path string) (*Config, error) {
 	viper.SetConfigFile(path)
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.SetDefault("urls.trusted_proxies", []string{"127.0.0.1", "::1"})
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("error reading config file: %w", err)
@@ -1,6 +1,8 @@
 package filter
 
 type Filter interface {
+	// Name identifies the filter in /1/limits
+	Name() string
 	Filter(content string) (string, error)
 }
 
@@ -8,6 +10,10 @@ type LengthFilter struct {
 	MaxLength int
 }
 
+func (f *LengthFilter) Name() string {
+	return "length"
+}
+
 func (f *LengthFilter) Filter(content string) (string, error) {
 	if len(content) > f.MaxLength {
 		return content[:f.MaxLength], nil
@@ -19,6 +25,10 @@ type LinesFilter struct {
 	MaxLines int
 }
 
+func (f *LinesFilter) Name() string {
+	return "lines"
+}
+
 func (f *LinesFilter) Filter(content string) (string, error) {
 	// Simple implementation, could be optimized for very large files
 	lines := 0
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LocalizedText is a text with translations keyed by locale, e.g. "en" or
+// "zh-CN". In YAML it is either a plain string, used for every locale, or a
+// map of locale to text. A plain string is stored under the empty key.
+type LocalizedText map[string]string
+
+func (t *LocalizedText) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var s string
+		if err := value.Decode(&s); err != nil {
+			return err
+		}
+		*t = LocalizedText{"": s}
+		return nil
+	}
+	var m map[string]string
+	if err := value.Decode(&m); err != nil {
+		return err
+	}
+	*t = m
+	return nil
+}
+
+// Get returns the text for locale, falling back to another variant of the
+// same language, then to the fallback locale, then to the plain text.
+func (t LocalizedText) Get(locale, fallback string) string {
+	keys := t.Locales()
+	if l := MatchLocale([]string{locale, fallback}, keys); l != "" {
+		return t[l]
+	}
+	if s, ok := t[""]; ok {
+		return s
+	}
+	if len(keys) > 0 {
+		return t[keys[0]]
+	}
+	return ""
+}
+
+// Locales returns the sorted locales the text is translated to.
+func (t LocalizedText) Locales() []string {
+	var keys []string
+	for k := range t {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MatchLocale picks the first entry of available that satisfies the
+// preferred locales, in order of preference. A locale matches itself
+// ignoring case, and otherwise any variant of its language, so "zh-TW"
+// is served "zh-CN" rather than nothing. It returns "" if nothing matches.
+func MatchLocale(preferred, available []string) string {
+	for _, want := range preferred {
+		if want == "" {
+			continue
+		}
+		for _, have := range available {
+			if strings.EqualFold(want, have) {
+				return have
+			}
+		}
+		for _, have := range available {
+			if strings.EqualFold(localeLanguage(want), localeLanguage(have)) {
+				return have
+			}
+		}
+	}
+	return ""
+}
+
+func localeLanguage(locale string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	lang, _, _ = strings.Cut(lang, "_")
+	return lang
+}
@@ -1,21 +1,93 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Log struct {
 	ID        string    `json:"id" bson:"_id"`
 	Content   string    `json:"content,omitempty" bson:"content"`
 	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+	// DeleteTokenHash is the SHA-256 of the token handed to the uploader;
+	// logs without one cannot be deleted through the API
+	DeleteTokenHash string `json:"-" bson:"delete_token_hash,omitempty"`
+	// PasswordHash is the argon2id hash of the password protecting the log,
+	// if any
+	PasswordHash string `json:"-" bson:"password_hash,omitempty"`
+	// MaxViews is how often the log can be read before it is deleted; zero
+	// means no limit
+	MaxViews int `json:"max_views,omitempty" bson:"max_views,omitempty"`
+	// Views counts the reads of a log with MaxViews
+	Views int `json:"views,omitempty" bson:"views"`
+}
+
+// Expired reports whether the log is past its expiry. A log without one
+// never expires.
+func (l *Log) Expired() bool {
+	return !l.ExpiresAt.IsZero() && time.Now().After(l.ExpiresAt)
+}
+
+// Bundle shares related logs under one ID, e.g. latest.log together with
+// the crash report it ended in. Each file is stored as a log of its own.
+type Bundle struct {
+	ID        string       `json:"id" bson:"_id"`
+	Files     []BundleFile `json:"files" bson:"files"`
+	CreatedAt time.Time    `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time    `json:"expires_at" bson:"expires_at"`
+}
+
+// Expired reports whether the bundle is past its expiry.
+func (b *Bundle) Expired() bool {
+	return !b.ExpiresAt.IsZero() && time.Now().After(b.ExpiresAt)
+}
+
+// BundleFile is a log in a bundle under the name it was uploaded with.
+type BundleFile struct {
+	Name  string `json:"name" bson:"name"`
+	LogID string `json:"id" bson:"log_id"`
+}
+
+// BundleAnalysis is the analysis of each file in a bundle together with
+// their merged result.
+type BundleAnalysis struct {
+	ID     string               `json:"id"`
+	Files  []BundleFileAnalysis `json:"files"`
+	Merged *AnalysisResult      `json:"merged"`
+}
+
+type BundleFileAnalysis struct {
+	Name     string          `json:"name"`
+	ID       string          `json:"id"`
+	Insights *AnalysisResult `json:"insights"`
 }
 
 type AnalysisResult struct {
-	ID          string       `json:"id"`
-	Name        string       `json:"name"`
-	Type        string       `json:"type"`
-	Version     string       `json:"version"`
-	Information []Info       `json:"information"`
-	Problems    []Problem    `json:"problems"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+	// Locale is the language the rule problems are written in
+	Locale string `json:"locale,omitempty"`
+	// Confidence is the share of the detection score that went to Type,
+	// from 0 to 1
+	Confidence  float64          `json:"confidence,omitempty"`
+	Candidates  []Candidate      `json:"candidates,omitempty"`
+	Information []Info           `json:"information"`
+	Problems    []Problem        `json:"problems"`
+	Mods        []Mod            `json:"mods,omitempty"`
+	Plugins     []Plugin         `json:"plugins,omitempty"`
+	Exceptions  []*JavaException `json:"exceptions,omitempty"`
+	RootCause   *JavaException   `json:"root_cause,omitempty"`
+}
+
+// Candidate is a log type that was considered during detection but lost to
+// the detected one.
+type Candidate struct {
+	Type  string  `json:"type"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
 }
 
 type Info struct {
@@ -24,11 +96,126 @@ type Info struct {
 }
 
 type Problem struct {
-	Severity string    `json:"severity"`
-	Message  string    `json:"message"`
+	Severity  string     `json:"severity"`
+	Message   string     `json:"message"`
 	Solutions []Solution `json:"solutions"`
 }
 
 type Solution struct {
-	Message string `json:"message"`
+	Message string          `json:"message"`
+	Action  *SolutionAction `json:"action,omitempty"`
+}
+
+// Solution action types
+const (
+	ActionLink    = "link"
+	ActionConfig  = "config"
+	ActionInstall = "install"
+	ActionRemove  = "remove"
+	ActionJVMFlag = "jvm_flag"
+)
+
+// SolutionAction is a concrete fix that clients can offer as a button
+// instead of prose. Which fields are set depends on Type:
+//   - link: URL to open, e.g. a download page
+//   - config: set Key to Value in File; an empty Value means the right
+//     value depends on the setup
+//   - install, remove: the Mod or Plugin, with an optional download URL
+//   - jvm_flag: Flag to add to the JVM arguments
+type SolutionAction struct {
+	Type   string `json:"type"`
+	Label  string `json:"label,omitempty"`
+	URL    string `json:"url,omitempty"`
+	File   string `json:"file,omitempty"`
+	Key    string `json:"key,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Mod    string `json:"mod,omitempty"`
+	Plugin string `json:"plugin,omitempty"`
+	Flag   string `json:"flag,omitempty"`
+}
+
+// Mod is a mod reported by the loader. Depending on where it was found some
+// fields may be empty, e.g. Forge's file scan only knows the jar name.
+type Mod struct {
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	File    string `json:"file,omitempty"`
+}
+
+// Plugin is a Bukkit-family or proxy plugin together with how far it got
+// during startup.
+type Plugin struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	File    string `json:"file,omitempty"`
+	// Status is "loaded", "enabled" or "failed"
+	Status string `json:"status"`
+	// Error is the root cause of the failure, if one was logged
+	Error string `json:"error,omitempty"`
+}
+
+// JavaException is a single throwable parsed from a stack trace, including
+// its "Caused by" chain and any "Suppressed" exceptions.
+type JavaException struct {
+	Class      string           `json:"class"`
+	Message    string           `json:"message,omitempty"`
+	Line       int              `json:"line"`
+	Frames     []StackFrame     `json:"frames,omitempty"`
+	More       int              `json:"more,omitempty"`
+	CausedBy   *JavaException   `json:"caused_by,omitempty"`
+	Suppressed []*JavaException `json:"suppressed,omitempty"`
+}
+
+type StackFrame struct {
+	Class  string `json:"class"`
+	Method string `json:"method"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+}
+
+// Package returns the package part of the frame's class name.
+func (f StackFrame) Package() string {
+	if i := strings.LastIndex(f.Class, "."); i >= 0 {
+		return f.Class[:i]
+	}
+	return ""
+}
+
+// RootCause follows the "Caused by" chain down to the innermost exception.
+func (e *JavaException) RootCause() *JavaException {
+	root := e
+	for root.CausedBy != nil {
+		root = root.CausedBy
+	}
+	return root
+}
+
+// Depth is the length of the "Caused by" chain below this exception.
+func (e *JavaException) Depth() int {
+	depth := 0
+	for c := e.CausedBy; c != nil; c = c.CausedBy {
+		depth++
+	}
+	return depth
+}
+
+// Walk calls fn for this exception and every cause and suppressed exception
+// reachable from it.
+func (e *JavaException) Walk(fn func(*JavaException)) {
+	fn(e)
+	if e.CausedBy != nil {
+		e.CausedBy.Walk(fn)
+	}
+	for _, s := range e.Suppressed {
+		s.Walk(fn)
+	}
+}
+
+// String formats the exception the way the JVM prints its header line.
+func (e *JavaException) String() string {
+	if e.Message == "" {
+		return e.Class
+	}
+	return e.Class + ": " + e.Message
 }
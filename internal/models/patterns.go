@@ -1,20 +1,118 @@
 package models
 
+import "gopkg.in/yaml.v3"
+
 type PatternConfig struct {
-	Detectors []DetectorPattern `yaml:"detectors"`
-	Analyzers []AnalyzerRule    `yaml:"analyzers"`
+	// FallbackLocale is used when a text has no variant for the requested
+	// locale. Defaults to "en".
+	FallbackLocale string            `yaml:"fallback_locale"`
+	Detectors      []DetectorPattern `yaml:"detectors"`
+	Analyzers      []AnalyzerRule    `yaml:"analyzers"`
 }
 
 type DetectorPattern struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	// Parent is the type this one is a variant of, e.g. paper for purpur.
+	// Rules limited to the parent type also apply to its children.
+	Parent string `yaml:"parent"`
+	// Pattern is the main signal for this type, worth Weight points (1 if
+	// unset). Signals add further evidence; every detector is scored and the
+	// highest total wins, ties going to the detector listed first.
+	Pattern string           `yaml:"pattern"`
+	Weight  float64          `yaml:"weight"`
+	Signals []DetectorSignal `yaml:"signals"`
+	// Version extracts the game version from the first non-empty capturing
+	// group.
+	Version string `yaml:"version"`
+	// Examples are logs that must be detected as this type,
+	// CounterExamples logs that must not.
+	Examples        []string `yaml:"examples"`
+	CounterExamples []string `yaml:"counter_examples"`
+}
+
+// DetectorSignal is a piece of evidence for a log type. Negative weights
+// count against it.
+type DetectorSignal struct {
+	Pattern string  `yaml:"pattern"`
+	Weight  float64 `yaml:"weight"`
+}
+
+type AnalyzerRule struct {
 	Name    string `yaml:"name"`
-	Type    string `yaml:"type"`
 	Pattern string `yaml:"pattern"`
+	// AllOf, AnyOf and NoneOf add further patterns that must all match, of
+	// which at least one must match, or of which none may match.
+	AllOf  []string `yaml:"all_of"`
+	AnyOf  []string `yaml:"any_of"`
+	NoneOf []string `yaml:"none_of"`
+	// WithinLines requires the patterns above to match within this many
+	// lines of a match of the first pattern (Pattern, else the first of
+	// AllOf, else any of AnyOf). Zero means anywhere in the log.
+	WithinLines int `yaml:"within_lines"`
+	// Types limits the rule to these detected log types or their parents.
+	Types []string `yaml:"types"`
+	// Versions limits the rule to a range of detected game versions, in the
+	// same format as ModCondition.Version.
+	Versions string `yaml:"versions"`
+	// Exception matches the class name of any parsed Java exception,
+	// including causes and suppressed ones.
+	Exception string `yaml:"exception"`
+	// FramePackage requires the matched exception to have a stack frame in
+	// this package (or a subpackage of it).
+	FramePackage string `yaml:"frame_package"`
+	// Mods must all hold against the extracted mod list. The versions of
+	// the mods that are present fill $1, $2... when the rule has no pattern.
+	Mods     []ModCondition `yaml:"mods"`
+	Severity string         `yaml:"severity"`
+	// Message and the solutions may be translated, see LocalizedText.
+	Message   LocalizedText  `yaml:"message"`
+	Solutions []RuleSolution `yaml:"solutions"`
+	// Examples are logs the rule must fire on, CounterExamples logs it must
+	// not fire on.
+	Examples        []RuleExample `yaml:"examples"`
+	CounterExamples []string      `yaml:"counter_examples"`
 }
 
-type AnalyzerRule struct {
-	Name      string     `yaml:"name"`
-	Pattern   string     `yaml:"pattern"`
-	Severity  string     `yaml:"severity"`
-	Message   string     `yaml:"message"`
-	Solutions []Solution `yaml:"solutions"`
+type RuleSolution struct {
+	Message LocalizedText `yaml:"message"`
+	Action  *RuleAction   `yaml:"action"`
+}
+
+// RuleAction is the YAML form of a SolutionAction. Every field may use
+// $1, $2... placeholders.
+type RuleAction struct {
+	Type   string        `yaml:"type"`
+	Label  LocalizedText `yaml:"label"`
+	URL    string        `yaml:"url"`
+	File   string        `yaml:"file"`
+	Key    string        `yaml:"key"`
+	Value  string        `yaml:"value"`
+	Mod    string        `yaml:"mod"`
+	Plugin string        `yaml:"plugin"`
+	Flag   string        `yaml:"flag"`
+}
+
+// RuleExample is a log a rule must fire on. When Message is set the expanded
+// problem message in Locale (the fallback locale if empty) must equal it. A
+// plain string is accepted as the log.
+type RuleExample struct {
+	Log     string `yaml:"log"`
+	Message string `yaml:"message"`
+	Locale  string `yaml:"locale"`
+}
+
+func (e *RuleExample) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&e.Log)
+	}
+	type plain RuleExample
+	return value.Decode((*plain)(e))
+}
+
+type ModCondition struct {
+	ID string `yaml:"id"`
+	// Version is a space separated list of comparisons, e.g. ">=0.14 <0.15"
+	Version string `yaml:"version"`
+	Absent  bool   `yaml:"absent"`
 }
@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"fmt"
+	"mclogs-go/internal/models"
+	"regexp"
+	"strings"
+)
+
+var (
+	crashDescriptionRe = regexp.MustCompile(`^Description: (.*)$`)
+	crashSectionRe     = regexp.MustCompile(`^-- (.+) --$`)
+)
+
+// crashDetails maps "-- System Details --" keys to the labels shown in the
+// insights. Keys differ between game versions, so several may map to the
+// same label; the first one present wins.
+var crashDetails = []struct {
+	Label string
+	Keys  []string
+}{
+	{"Minecraft Version", []string{"Minecraft Version"}},
+	{"Operating System", []string{"Operating System"}},
+	{"Java Version", []string{"Java Version"}},
+	{"Java VM", []string{"Java VM Version"}},
+	{"Memory", []string{"Memory"}},
+	{"JVM Flags", []string{"JVM Flags"}},
+	{"CPU", []string{"Processor Name", "CPUs"}},
+	{"GPU", []string{"Graphics card #0 name", "Backend API", "GL info"}},
+	{"Launched Version", []string{"Launched Version"}},
+}
+
+// maxCrashDetails bounds how many "System Details" lines are kept.
+const maxCrashDetails = 200
+
+type crashDetail struct {
+	key   string
+	value string
+}
+
+// crashReportParser reads Minecraft crash-reports/*.txt files.
+type crashReportParser struct {
+	description     string
+	descriptionLine int
+	section         string
+	details         []*crashDetail
+}
+
+func (p *crashReportParser) feed(lineNo int, line string) {
+	trimmed := strings.TrimSpace(line)
+
+	if p.description == "" {
+		if m := crashDescriptionRe.FindStringSubmatch(line); m != nil {
+			p.description = m[1]
+			p.descriptionLine = lineNo
+			return
+		}
+	}
+
+	if m := crashSectionRe.FindStringSubmatch(trimmed); m != nil {
+		p.section = m[1]
+		return
+	}
+
+	if p.section != "System Details" || trimmed == "" || trimmed == "Details:" {
+		return
+	}
+
+	// Deeper indented lines and FML state tables belong to the previous key,
+	// the mod list parser takes care of them
+	if indentWidth(line) > indentWidth("\t") || strings.HasPrefix(trimmed, "|") {
+		return
+	}
+
+	if key, value, ok := strings.Cut(trimmed, ":"); ok && len(p.details) < maxCrashDetails {
+		p.details = append(p.details, &crashDetail{key: key, value: strings.TrimSpace(value)})
+	}
+}
+
+func (p *crashReportParser) finish(result *models.AnalysisResult) {
+	if p.description == "" {
+		return
+	}
+
+	if d := p.detail("Minecraft Version"); d != nil && d.value != "" {
+		result.Version = d.value
+	}
+
+	for _, cd := range crashDetails {
+		for _, key := range cd.Keys {
+			if d := p.detail(key); d != nil && d.value != "" {
+				result.Information = append(result.Information, models.Info{Label: label(result.Locale, cd.Label), Value: d.value})
+				break
+			}
+		}
+	}
+
+	if len(result.Mods) > 0 {
+		result.Information = append(result.Information, models.Info{Label: label(result.Locale, "Loaded Mods"), Value: fmt.Sprint(len(result.Mods))})
+	}
+
+	// The throwable is printed right below the description
+	var throwable *models.JavaException
+	for _, ex := range result.Exceptions {
+		if ex.Line > p.descriptionLine {
+			throwable = ex
+			break
+		}
+	}
+
+	locale := result.Locale
+	problem := models.Problem{
+		Severity: "critical",
+		Message:  localize(locale, crashedText, p.description),
+	}
+	if throwable != nil {
+		root := throwable.RootCause()
+		problem.Message = localize(locale, crashedWithCauseText, p.description, root.String())
+		if origin := firstForeignFrame(root); origin != nil {
+			problem.Solutions = append(problem.Solutions, models.Solution{
+				Message: localize(locale, crashOriginText, origin.Class),
+			})
+		}
+	}
+	problem.Solutions = append(problem.Solutions, models.Solution{
+		Message: localize(locale, crashWalkthroughText),
+	})
+
+	result.Problems = append(result.Problems, problem)
+}
+
+func (p *crashReportParser) detail(key string) *crashDetail {
+	for _, d := range p.details {
+		if d.key == key {
+			return d
+		}
+	}
+	return nil
+}
+
+// firstForeignFrame returns the topmost frame that is not part of the JDK or
+// the game itself, which is usually the mod responsible for the crash.
+func firstForeignFrame(ex *models.JavaException) *models.StackFrame {
+	for i, frame := range ex.Frames {
+		switch {
+		case strings.HasPrefix(frame.Class, "java."),
+			strings.HasPrefix(frame.Class, "jdk."),
+			strings.HasPrefix(frame.Class, "sun."),
+			strings.HasPrefix(frame.Class, "net.minecraft."),
+			strings.HasPrefix(frame.Class, "com.mojang."):
+			continue
+		}
+		return &ex.Frames[i]
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"mclogs-go/internal/models"
+	"regexp"
+	"slices"
+	"sort"
+)
+
+// maxCandidates is how many runner-up types are reported.
+const maxCandidates = 3
+
+type compiledDetector struct {
+	models.DetectorPattern
+	signals []compiledSignal
+	version *regexp.Regexp
+}
+
+type compiledSignal struct {
+	re     *regexp.Regexp
+	weight float64
+}
+
+func compileDetector(det models.DetectorPattern) (*compiledDetector, error) {
+	if det.Pattern == "" && len(det.Signals) == 0 {
+		return nil, errors.New("detector has no pattern or signals")
+	}
+
+	cd := &compiledDetector{DetectorPattern: det}
+	if det.Pattern != "" {
+		re, err := regexp.Compile(det.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		cd.signals = append(cd.signals, compiledSignal{re: re, weight: defaultWeight(det.Weight)})
+	}
+	for _, sig := range det.Signals {
+		re, err := regexp.Compile(sig.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signal pattern: %w", err)
+		}
+		cd.signals = append(cd.signals, compiledSignal{re: re, weight: defaultWeight(sig.Weight)})
+	}
+	if det.Version != "" {
+		var err error
+		if cd.version, err = regexp.Compile(det.Version); err != nil {
+			return nil, fmt.Errorf("invalid version pattern: %w", err)
+		}
+	}
+	return cd, nil
+}
+
+func defaultWeight(w float64) float64 {
+	if w == 0 {
+		return 1
+	}
+	return w
+}
+
+// score adds up the weights of the signals found in the log. Each signal
+// counts once, no matter how often it matches. Signals whose literals the
+// prefilter did not find are not run.
+func (d *compiledDetector) score(content string, p *prefilter, found []bool) float64 {
+	var total float64
+	for _, sig := range d.signals {
+		if p.mayMatch(sig.re, found) && sig.re.MatchString(content) {
+			total += sig.weight
+		}
+	}
+	return total
+}
+
+type detectorScore struct {
+	det   *compiledDetector
+	score float64
+}
+
+// detect scores every detector and fills in the type, name, version,
+// confidence and runner-up candidates of the result. found holds the
+// prefilter literals in content.
+func (e *Engine) detect(content string, found []bool, result *models.AnalysisResult) {
+	scores := make([]float64, len(e.detectors))
+	for i, det := range e.detectors {
+		scores[i] = det.score(content, e.prefilter, found)
+	}
+	e.applyDetection(result, scores, func(det *compiledDetector) []string {
+		return det.version.FindStringSubmatch(content)
+	})
+}
+
+// applyDetection picks the winner from the scores of e.detectors (by index)
+// and fills in the result. findVersion returns the submatches of a
+// detector's version regex.
+func (e *Engine) applyDetection(result *models.AnalysisResult, detScores []float64, findVersion func(*compiledDetector) []string) {
+	var scores []detectorScore
+	for i, det := range e.detectors {
+		if s := detScores[i]; s > 0 {
+			scores = append(scores, detectorScore{det: det, score: s})
+		}
+	}
+	if len(scores) == 0 {
+		return
+	}
+	// Stable, so ties go to the detector listed first
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].score > scores[j].score })
+
+	winner := scores[0].det
+	result.Name = winner.Name
+	result.Type = winner.Type
+
+	// A parent scoring as well backs the winner up rather than competing with it
+	ancestors := e.lineage(winner.Type)
+	total := scores[0].score
+	for _, s := range scores[1:] {
+		if !slices.Contains(ancestors, s.det.Type) {
+			total += s.score
+		}
+		if len(result.Candidates) < maxCandidates {
+			result.Candidates = append(result.Candidates, models.Candidate{
+				Type:  s.det.Type,
+				Name:  s.det.Name,
+				Score: s.score,
+			})
+		}
+	}
+	result.Confidence = math.Round(scores[0].score/total*100) / 100
+
+	// Children usually print their version the same way as their parents
+	for _, t := range ancestors {
+		det := e.detectorFor(t)
+		if det == nil || det.version == nil {
+			continue
+		}
+		if m := findVersion(det); len(m) > 1 {
+			if v := firstNonEmpty(m[1:]...); v != "" {
+				result.Version = v
+				break
+			}
+		}
+	}
+}
+
+// lineage returns the type followed by its parents, nearest first.
+func (e *Engine) lineage(logType string) []string {
+	types := []string{logType}
+	for parent := e.parents[logType]; parent != "" && !slices.Contains(types, parent); parent = e.parents[parent] {
+		types = append(types, parent)
+	}
+	return types
+}
+
+func (e *Engine) detectorFor(logType string) *compiledDetector {
+	for _, det := range e.detectors {
+		if det.Type == logType {
+			return det
+		}
+	}
+	return nil
+}
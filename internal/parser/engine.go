@@ -1,89 +1,245 @@
 package parser
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"mclogs-go/internal/models"
 	"os"
 	"regexp"
+	"slices"
+	"strings"
 	"sync"
 
 	"gopkg.in/yaml.v3"
 )
 
+// extractor pulls structured data out of a log one line at a time. finish
+// is called in registration order, so later extractors can rely on the
+// exceptions already being in the result.
+type extractor interface {
+	feed(lineNo int, line string)
+	finish(result *models.AnalysisResult)
+}
+
+// typeExtractors holds the dedicated parsers for detected log types.
+var typeExtractors = map[string]func() extractor{
+	"crash-report":      func() extractor { return &crashReportParser{} },
+	"hs-err":            func() extractor { return &hsErrParser{} },
+	"hmcl":              func() extractor { return newLauncherParser(hmclFields) },
+	"pcl2":              func() extractor { return newLauncherParser(pclFields) },
+	"prism":             func() extractor { return newLauncherParser(prismFields) },
+	"multimc":           func() extractor { return newLauncherParser(prismFields) },
+	"official-launcher": func() extractor { return newLauncherParser(officialLauncherFields) },
+}
+
 type Engine struct {
 	config models.PatternConfig
+	// hash of the patterns file the engine was built from
+	version   string
+	detectors []*compiledDetector
+	// parent type of each detected type
+	parents map[string]string
+	rules   []*compiledRule
+	// locales the rules are translated to, the fallback first
+	locales  []string
+	fallback string
+	// rules out regexes whose literals are not in a log
+	prefilter *prefilter
+	// the regexes above prepared for AnalyzeReader
+	lineRegexes map[*regexp.Regexp]*lineRegex
+	multiline   bool
 }
 
+const defaultLocale = "en"
+
 func NewEngine(patternsPath string) (*Engine, error) {
-	data, err := os.ReadFile(patternsPath)
+	config, version, err := readPatterns(patternsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Engine{config: *config, version: version, parents: make(map[string]string)}
+	for _, det := range config.Detectors {
+		cd, err := compileDetector(det)
+		if err != nil {
+			return nil, fmt.Errorf("detector %q: %w", det.Name, err)
+		}
+		e.detectors = append(e.detectors, cd)
+		if det.Parent != "" {
+			e.parents[det.Type] = det.Parent
+		}
+	}
+	for _, rule := range config.Analyzers {
+		cr, err := compileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("analyzer %q: %w", rule.Name, err)
+		}
+		e.rules = append(e.rules, cr)
+	}
+
+	e.fallback = config.FallbackLocale
+	if e.fallback == "" {
+		e.fallback = defaultLocale
+	}
+	e.locales = []string{e.fallback}
+	for _, rule := range config.Analyzers {
+		texts := []models.LocalizedText{rule.Message}
+		for _, s := range rule.Solutions {
+			texts = append(texts, s.Message)
+		}
+		for _, t := range texts {
+			for _, l := range t.Locales() {
+				if !slices.Contains(e.locales, l) {
+					e.locales = append(e.locales, l)
+				}
+			}
+		}
+	}
+	slices.Sort(e.locales[1:])
+
+	e.prefilter = newPrefilter(e.regexes())
+	e.prepareStream()
+	return e, nil
+}
+
+// regexes lists every regex matched against log text, in load order.
+func (e *Engine) regexes() []*regexp.Regexp {
+	var res []*regexp.Regexp
+	for _, det := range e.detectors {
+		for _, sig := range det.signals {
+			res = append(res, sig.re)
+		}
+		if det.version != nil {
+			res = append(res, det.version)
+		}
+	}
+	for _, r := range e.rules {
+		if r.pattern != nil {
+			res = append(res, r.pattern)
+		}
+		res = append(res, r.allOf...)
+		res = append(res, r.anyOf...)
+		res = append(res, r.noneOf...)
+	}
+	return res
+}
+
+// LoadPatterns reads a patterns file without compiling it.
+func LoadPatterns(path string) (*models.PatternConfig, error) {
+	config, _, err := readPatterns(path)
+	return config, err
+}
+
+// readPatterns reads a patterns file and returns the hash of its content.
+func readPatterns(path string) (*models.PatternConfig, string, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read patterns file: %w", err)
+		return nil, "", fmt.Errorf("failed to read patterns file: %w", err)
 	}
 
 	var config models.PatternConfig
 	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal patterns: %w", err)
+		return nil, "", fmt.Errorf("failed to unmarshal patterns: %w", err)
+	}
+	return &config, patternsVersion(data), nil
+}
+
+// patternsVersion identifies the content of a patterns file.
+func patternsVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Version identifies the rule set: it changes whenever the content of the
+// patterns file does.
+func (e *Engine) Version() string {
+	return e.version
+}
+
+// Locales returns the locales the rules are available in, the fallback
+// locale first.
+func (e *Engine) Locales() []string {
+	return e.locales
+}
+
+// resolveLocale returns the supported locale closest to the requested one,
+// or the fallback locale.
+func (e *Engine) resolveLocale(locale string) string {
+	if l := models.MatchLocale([]string{locale}, e.locales); l != "" {
+		return l
+	}
+	return e.fallback
+}
+
+// Analyze analyzes a log, writing the problems in the given locale. An empty
+// or unsupported locale means the fallback locale.
+func (e *Engine) Analyze(content, locale string) *models.AnalysisResult {
+	in := e.inspect(content, locale)
+	result := in.result
+
+	// 3. Run Analyzers Concurrently, keeping the rule order in the output
+	problems := make([]*models.Problem, len(e.rules))
+
+	var wg sync.WaitGroup
+	wg.Add(len(e.rules))
+	for i, rule := range e.rules {
+		go func(i int, r *compiledRule) {
+			defer wg.Done()
+
+			if groups, ok := r.match(in); ok {
+				problems[i] = r.problem(groups, result.Locale, e.fallback)
+			}
+		}(i, rule)
 	}
+	wg.Wait()
 
-	return &Engine{config: config}, nil
+	for _, p := range problems {
+		if p != nil {
+			result.Problems = append(result.Problems, *p)
+		}
+	}
+	return result
 }
 
-func (e *Engine) Analyze(content string) *models.AnalysisResult {
+// inspect detects the log type and extracts everything the rules match
+// against, with what the extractors write in the given locale.
+func (e *Engine) inspect(content, locale string) *ruleInput {
 	result := &models.AnalysisResult{
 		Name:    "Unknown Log",
 		Type:    "unknown",
 		Version: "unknown",
+		Locale:  e.resolveLocale(locale),
 	}
 
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-
 	// 1. Detect Log Type
-	for _, det := range e.config.Detectors {
-		re, err := regexp.Compile(det.Pattern)
-		if err != nil {
-			continue
-		}
-		if re.MatchString(content) {
-			result.Name = det.Name
-			result.Type = det.Type
-			break
+	found := e.prefilter.scan(content)
+	e.detect(content, found, result)
+
+	// 2. Extract exceptions and type-specific details
+	extractors := []extractor{&stackTraceParser{}, &modListParser{}, &pluginListParser{}}
+	if newExtractor, ok := typeExtractors[result.Type]; ok {
+		extractors = append(extractors, newExtractor())
+	}
+	for i, line := range splitLines(content) {
+		for _, ex := range extractors {
+			ex.feed(i+1, line)
 		}
 	}
+	for _, ex := range extractors {
+		ex.finish(result)
+	}
 
-	// 2. Run Analyzers Concurrently
-	wg.Add(len(e.config.Analyzers))
-	for _, rule := range e.config.Analyzers {
-		go func(r models.AnalyzerRule) {
-			defer wg.Done()
-			
-			re, err := regexp.Compile(r.Pattern)
-			if err != nil {
-				return
-			}
-
-			matches := re.FindAllStringSubmatch(content, -1)
-			if len(matches) > 0 {
-				mu.Lock()
-				msg := r.Message
-				// Dynamic replacement for capturing groups ($1, $2, $3...)
-				if len(matches[0]) > 1 {
-					for i := 1; i < len(matches[0]); i++ {
-						placeholder := fmt.Sprintf("$%d", i)
-						msg = regexp.MustCompile(regexp.QuoteMeta(placeholder)).ReplaceAllString(msg, matches[0][i])
-					}
-				}
+	in := newRuleInput(content, result)
+	in.types = e.lineage(result.Type)
+	in.filter, in.found = e.prefilter, found
+	return in
+}
 
-				result.Problems = append(result.Problems, models.Problem{
-					Severity:  r.Severity,
-					Message:   msg,
-					Solutions: r.Solutions,
-				})
-				mu.Unlock()
-			}
-		}(rule)
+func splitLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
 	}
-
-	wg.Wait()
-	return result
+	return lines
 }
@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"mclogs-go/internal/models"
+	"slices"
+	"time"
+)
+
+// Explanation shows how the engine reached its result for a log.
+type Explanation struct {
+	Result    *models.AnalysisResult
+	Detectors []DetectorExplanation
+	Rules     []RuleExplanation
+}
+
+type DetectorExplanation struct {
+	Name    string
+	Type    string
+	Score   float64
+	Signals []SignalExplanation
+}
+
+type SignalExplanation struct {
+	Pattern string
+	Weight  float64
+	Matched bool
+}
+
+type RuleExplanation struct {
+	Name    string
+	Matched bool
+	// Skipped is set when the prefilter ruled the rule out without running it
+	Skipped bool
+	// Lines the rule's patterns or exceptions were found on
+	Lines    []int
+	Duration time.Duration
+}
+
+// Explain analyzes a log like Analyze does, but runs the rules one after
+// another so each can be timed, and records every detector's score.
+func (e *Engine) Explain(content, locale string) *Explanation {
+	in := e.inspect(content, locale)
+	x := &Explanation{Result: in.result}
+
+	for _, det := range e.detectors {
+		de := DetectorExplanation{Name: det.Name, Type: det.Type}
+		for _, sig := range det.signals {
+			matched := sig.re.MatchString(content)
+			if matched {
+				de.Score += sig.weight
+			}
+			de.Signals = append(de.Signals, SignalExplanation{Pattern: sig.re.String(), Weight: sig.weight, Matched: matched})
+		}
+		x.Detectors = append(x.Detectors, de)
+	}
+
+	for _, r := range e.rules {
+		start := time.Now()
+		groups, ok := r.match(in)
+		re := RuleExplanation{Name: r.Name, Matched: ok, Skipped: !r.possible(in), Duration: time.Since(start)}
+		if ok {
+			in.result.Problems = append(in.result.Problems, *r.problem(groups, in.result.Locale, e.fallback))
+			re.Lines = r.lines(in)
+		}
+		x.Rules = append(x.Rules, re)
+	}
+	return x
+}
+
+// lines returns where the rule's text patterns match, or for rules without
+// any, the lines of the exceptions it matched.
+func (r *compiledRule) lines(in *ruleInput) []int {
+	seen := make(map[int]bool)
+	var lines []int
+	addAll := func(l []int) {
+		for _, n := range l {
+			if !seen[n] {
+				seen[n] = true
+				lines = append(lines, n)
+			}
+		}
+	}
+
+	if r.pattern != nil {
+		addAll(in.matchLines(r.pattern))
+	}
+	for _, re := range r.allOf {
+		addAll(in.matchLines(re))
+	}
+	for _, re := range r.anyOf {
+		addAll(in.matchLines(re))
+	}
+	if len(lines) == 0 && (r.exception != nil || r.FramePackage != "") {
+		for _, top := range in.result.Exceptions {
+			top.Walk(func(ex *models.JavaException) {
+				if r.exception != nil && !r.exception.MatchString(ex.Class) {
+					return
+				}
+				if r.FramePackage != "" && !hasFrameInPackage(ex, r.FramePackage) {
+					return
+				}
+				addAll([]int{ex.Line})
+			})
+		}
+	}
+	slices.Sort(lines)
+	return lines
+}
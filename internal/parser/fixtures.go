@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"fmt"
+	"mclogs-go/internal/models"
+)
+
+// FixtureFailure is an example or counter-example from the patterns file
+// that did not behave as declared.
+type FixtureFailure struct {
+	// Kind is "detector" or "rule"
+	Kind string
+	Name string
+	// Counter is set for counter_examples; Index is the position in the list
+	Counter bool
+	Index   int
+	Reason  string
+}
+
+func (f FixtureFailure) Error() string {
+	list := "examples"
+	if f.Counter {
+		list = "counter_examples"
+	}
+	return fmt.Sprintf("%s %q: %s[%d]: %s", f.Kind, f.Name, list, f.Index, f.Reason)
+}
+
+// CheckFixtures runs every detector and rule against its own examples and
+// counter-examples.
+func (e *Engine) CheckFixtures() []FixtureFailure {
+	var failures []FixtureFailure
+	for _, det := range e.detectors {
+		failures = append(failures, e.checkDetector(det)...)
+	}
+	for _, rule := range e.rules {
+		failures = append(failures, e.checkRule(rule)...)
+	}
+	return failures
+}
+
+func (e *Engine) checkDetector(det *compiledDetector) []FixtureFailure {
+	var failures []FixtureFailure
+	fail := func(counter bool, i int, reason string) {
+		failures = append(failures, FixtureFailure{Kind: "detector", Name: det.Name, Counter: counter, Index: i, Reason: reason})
+	}
+
+	for i, example := range det.Examples {
+		result := &models.AnalysisResult{Type: "unknown"}
+		if e.detect(example, e.prefilter.scan(example), result); result.Type != det.Type {
+			fail(false, i, fmt.Sprintf("detected as %q", result.Type))
+		}
+	}
+	for i, example := range det.CounterExamples {
+		result := &models.AnalysisResult{Type: "unknown"}
+		if e.detect(example, e.prefilter.scan(example), result); result.Type == det.Type {
+			fail(true, i, "detected as this type")
+		}
+	}
+	return failures
+}
+
+func (e *Engine) checkRule(r *compiledRule) []FixtureFailure {
+	var failures []FixtureFailure
+	fail := func(counter bool, i int, reason string) {
+		failures = append(failures, FixtureFailure{Kind: "rule", Name: r.Name, Counter: counter, Index: i, Reason: reason})
+	}
+
+	for i, example := range r.Examples {
+		groups, ok := r.match(e.inspect(example.Log, example.Locale))
+		if !ok {
+			fail(false, i, "rule did not fire")
+			continue
+		}
+		locale := e.resolveLocale(example.Locale)
+		if msg := r.problem(groups, locale, e.fallback).Message; example.Message != "" && msg != example.Message {
+			fail(false, i, fmt.Sprintf("message is %q, want %q", msg, example.Message))
+		}
+	}
+	for i, example := range r.CounterExamples {
+		if _, ok := r.match(e.inspect(example, "")); ok {
+			fail(true, i, "rule fired")
+		}
+	}
+	return failures
+}
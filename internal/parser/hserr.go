@@ -0,0 +1,261 @@
+package parser
+
+import (
+	"mclogs-go/internal/models"
+	"regexp"
+	"strings"
+)
+
+var (
+	hsErrSignalRe      = regexp.MustCompile(`^#\s+((?:EXCEPTION_|SIG)[A-Z_]+)\s+\((0x[0-9a-fA-F]+)\) at pc=`)
+	hsErrErrorRe       = regexp.MustCompile(`^#\s+(Out of Memory Error|Internal Error)\s+\(([^)]*)\)`)
+	hsErrJREVersionRe  = regexp.MustCompile(`^# JRE version: (.+)$`)
+	hsErrJavaVMRe      = regexp.MustCompile(`^# Java VM: (.+)$`)
+	hsErrFrameRe       = regexp.MustCompile(`^#\s+([CjJvVA])\s+(?:\[([^+\]]+)(?:\+0x[0-9a-fA-F]+)?\]\s*)?(.*)$`)
+	hsErrHeapSizeRe    = regexp.MustCompile(`^Heap address: [^,]+, size: (\d+ [KMG]B)`)
+	hsErrHeapUsageRe   = regexp.MustCompile(`^\s+(.+heap)\s+total (\d+[KMG]), used (\d+[KMG])`)
+	hsErrMemoryRe      = regexp.MustCompile(`^Memory: \S+ page, (?:system-wide )?physical (\S+) \((\S+) free\)`)
+	hsErrCPURe         = regexp.MustCompile(`^CPU: total (\d+)`)
+	hsErrHostRe        = regexp.MustCompile(`^Host: (.+)$`)
+	hsErrJvmArgsRe     = regexp.MustCompile(`^jvm_args: (.+)$`)
+	hsErrCommandRe     = regexp.MustCompile(`^java_command: (.+)$`)
+	hsErrGameVersionRe = regexp.MustCompile(`--version (\S+)`)
+	hsErrNativeOOMRe   = regexp.MustCompile(`^# Native memory allocation \((\w+)\) failed to (?:allocate|map) (\d+) bytes`)
+)
+
+// nativeLibraries lists native libraries that are known to take the JVM down
+// with them, keyed by lower-cased file name.
+var nativeLibraries = map[string]nativeCrash{
+	"atio6axx.dll":        amdDriverCrash,
+	"atioglxx.dll":        amdDriverCrash,
+	"atig6pxx.dll":        amdDriverCrash,
+	"atig6txx.dll":        amdDriverCrash,
+	"nvoglv64.dll":        nvidiaDriverCrash,
+	"nvoglv32.dll":        nvidiaDriverCrash,
+	"ig9icd64.dll":        intelDriverCrash,
+	"ig8icd64.dll":        intelDriverCrash,
+	"ig75icd64.dll":       intelDriverCrash,
+	"ig7icd64.dll":        intelDriverCrash,
+	"ig4icd64.dll":        intelDriverCrash,
+	"igxelpicd64.dll":     intelDriverCrash,
+	"libnvidia-glcore.so": nvidiaDriverCrash,
+	"libglx_nvidia.so.0":  nvidiaDriverCrash,
+	"radeonsi_dri.so":     mesaDriverCrash,
+	"iris_dri.so":         mesaDriverCrash,
+	"lwjgl.dll":           lwjglCrash,
+	"liblwjgl.so":         lwjglCrash,
+	"openal.dll": {
+		Message: models.LocalizedText{"en": "The JVM crashed inside the OpenAL audio library.", "zh-CN": "JVM 在 OpenAL 音频库中崩溃。"},
+		Solutions: []models.LocalizedText{
+			{"en": "Update your audio drivers.", "zh-CN": "更新音频驱动。"},
+			{"en": "Disconnect virtual audio devices and try again.", "zh-CN": "断开虚拟音频设备后重试。"},
+		},
+	},
+}
+
+type nativeCrash struct {
+	Message   models.LocalizedText
+	Solutions []models.LocalizedText
+}
+
+var (
+	removeShadersSolution = models.LocalizedText{
+		"en":    "Remove shader packs and rendering mods to check whether they trigger the crash.",
+		"zh-CN": "移除光影包和渲染类模组，检查它们是否会触发崩溃。",
+	}
+	amdDriverCrash = nativeCrash{
+		Message: models.LocalizedText{"en": "The JVM crashed in the AMD graphics driver.", "zh-CN": "JVM 在 AMD 显卡驱动中崩溃。"},
+		Solutions: []models.LocalizedText{
+			{"en": "Update or cleanly reinstall the AMD graphics driver.", "zh-CN": "更新或彻底重装 AMD 显卡驱动。"},
+			removeShadersSolution,
+		},
+	}
+	nvidiaDriverCrash = nativeCrash{
+		Message: models.LocalizedText{"en": "The JVM crashed in the NVIDIA graphics driver.", "zh-CN": "JVM 在 NVIDIA 显卡驱动中崩溃。"},
+		Solutions: []models.LocalizedText{
+			{"en": "Update or cleanly reinstall the NVIDIA graphics driver.", "zh-CN": "更新或彻底重装 NVIDIA 显卡驱动。"},
+			removeShadersSolution,
+		},
+	}
+	intelDriverCrash = nativeCrash{
+		Message: models.LocalizedText{"en": "The JVM crashed in the Intel graphics driver.", "zh-CN": "JVM 在 Intel 显卡驱动中崩溃。"},
+		Solutions: []models.LocalizedText{
+			{"en": "Update the Intel graphics driver from Intel's website rather than Windows Update.", "zh-CN": "从 Intel 官网而不是 Windows 更新安装 Intel 显卡驱动。"},
+			{"en": "On laptops, run Java on the dedicated GPU instead of the integrated one.", "zh-CN": "在笔记本上让 Java 使用独立显卡而不是集成显卡。"},
+		},
+	}
+	mesaDriverCrash = nativeCrash{
+		Message: models.LocalizedText{"en": "The JVM crashed in the Mesa graphics driver.", "zh-CN": "JVM 在 Mesa 显卡驱动中崩溃。"},
+		Solutions: []models.LocalizedText{
+			{"en": "Update Mesa to the latest version from your distribution.", "zh-CN": "从发行版的软件源将 Mesa 更新到最新版本。"},
+		},
+	}
+	lwjglCrash = nativeCrash{
+		Message: models.LocalizedText{"en": "The JVM crashed inside the LWJGL natives.", "zh-CN": "JVM 在 LWJGL 本地库中崩溃。"},
+		Solutions: []models.LocalizedText{
+			{"en": "Reinstall the game version so the natives are extracted again.", "zh-CN": "重新安装该游戏版本，以重新解压本地库。"},
+			{"en": "Make sure the Java architecture (64-bit) matches the natives.", "zh-CN": "确保 Java 的架构（64 位）与本地库一致。"},
+		},
+	}
+	jvmCrash = nativeCrash{
+		Message: models.LocalizedText{"en": "The JVM crashed inside its own code.", "zh-CN": "JVM 在自身代码中崩溃。"},
+		Solutions: []models.LocalizedText{
+			{"en": "Update Java to the latest release of your major version.", "zh-CN": "将 Java 更新到当前大版本的最新发行版。"},
+			{"en": "Try a different Java distribution.", "zh-CN": "尝试其他 Java 发行版。"},
+			{"en": "Test your RAM if the crash keeps happening in different places.", "zh-CN": "如果每次崩溃的位置都不同，请检测内存条。"},
+		},
+	}
+)
+
+// hsErrParser reads HotSpot fatal error logs (hs_err_pid*.log).
+type hsErrParser struct {
+	signal       string
+	errorKind    string
+	jreVersion   string
+	javaVM       string
+	frameType    string
+	library      string
+	frame        string
+	nativeOOM    bool
+	heapSize     string
+	heapUsage    []string
+	memory       []string
+	cpu          string
+	host         string
+	os           string
+	jvmArgs      string
+	gameVersion  string
+	expectOS     bool
+	sawFrameLine bool
+}
+
+func (p *hsErrParser) feed(lineNo int, line string) {
+	if p.expectOS {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			p.os = trimmed
+			p.expectOS = false
+		}
+		return
+	}
+
+	switch {
+	case p.sawFrameLine:
+		p.sawFrameLine = false
+		if m := hsErrFrameRe.FindStringSubmatch(line); m != nil {
+			p.frameType, p.library, p.frame = m[1], m[2], strings.TrimSpace(m[3])
+		}
+	case strings.HasPrefix(line, "# Problematic frame:"):
+		p.sawFrameLine = true
+	case line == "OS:":
+		p.expectOS = true
+	case strings.HasPrefix(line, "OS: "):
+		if p.os == "" {
+			p.os = strings.TrimSpace(strings.TrimPrefix(line, "OS: "))
+		}
+	}
+
+	if m := hsErrSignalRe.FindStringSubmatch(line); m != nil {
+		p.signal = m[1] + " (" + m[2] + ")"
+	} else if m := hsErrErrorRe.FindStringSubmatch(line); m != nil {
+		p.errorKind = m[1] + " (" + m[2] + ")"
+	} else if m := hsErrJREVersionRe.FindStringSubmatch(line); m != nil {
+		p.jreVersion = m[1]
+	} else if m := hsErrJavaVMRe.FindStringSubmatch(line); m != nil {
+		p.javaVM = m[1]
+	} else if m := hsErrNativeOOMRe.FindStringSubmatch(line); m != nil {
+		p.nativeOOM = true
+	} else if m := hsErrHeapSizeRe.FindStringSubmatch(line); m != nil {
+		p.heapSize = m[1]
+	} else if m := hsErrHeapUsageRe.FindStringSubmatch(line); m != nil && p.heapUsage == nil {
+		p.heapUsage = m[1:]
+	} else if m := hsErrMemoryRe.FindStringSubmatch(line); m != nil {
+		p.memory = m[1:]
+	} else if m := hsErrCPURe.FindStringSubmatch(line); m != nil {
+		p.cpu = m[1]
+	} else if m := hsErrHostRe.FindStringSubmatch(line); m != nil {
+		p.host = strings.Join(strings.Fields(m[1]), " ")
+	} else if m := hsErrJvmArgsRe.FindStringSubmatch(line); m != nil {
+		p.jvmArgs = m[1]
+	} else if m := hsErrCommandRe.FindStringSubmatch(line); m != nil {
+		if v := hsErrGameVersionRe.FindStringSubmatch(m[1]); v != nil {
+			p.gameVersion = v[1]
+		}
+	}
+}
+
+func (p *hsErrParser) finish(result *models.AnalysisResult) {
+	if p.gameVersion != "" {
+		result.Version = p.gameVersion
+	}
+
+	locale := result.Locale
+	var heapUsage, memory, cpu string
+	if p.heapUsage != nil {
+		heapUsage = localize(locale, heapUsageText, p.heapUsage[2], p.heapUsage[1], p.heapUsage[0])
+	}
+	if p.memory != nil {
+		memory = localize(locale, memoryFreeText, p.memory[0], p.memory[1])
+	}
+	if p.cpu != "" {
+		cpu = localize(locale, cpuThreadsText, p.cpu)
+	}
+
+	info := []models.Info{
+		{Label: "Error", Value: firstNonEmpty(p.signal, p.errorKind)},
+		{Label: "Problematic Frame", Value: strings.TrimSpace(p.library + " " + p.frame)},
+		{Label: "JRE Version", Value: p.jreVersion},
+		{Label: "Java VM", Value: p.javaVM},
+		{Label: "Heap Size", Value: p.heapSize},
+		{Label: "Heap Usage", Value: heapUsage},
+		{Label: "JVM Arguments", Value: p.jvmArgs},
+		{Label: "Operating System", Value: p.os},
+		{Label: "Host", Value: p.host},
+		{Label: "CPU", Value: cpu},
+		{Label: "Physical Memory", Value: memory},
+	}
+	for _, i := range info {
+		if i.Value != "" {
+			i.Label = label(locale, i.Label)
+			result.Information = append(result.Information, i)
+		}
+	}
+
+	if p.nativeOOM || strings.HasPrefix(p.errorKind, "Out of Memory Error") {
+		problem := models.Problem{Severity: "critical", Message: localize(locale, nativeOOMText)}
+		for _, s := range nativeOOMSolutions {
+			problem.Solutions = append(problem.Solutions, models.Solution{Message: localize(locale, s)})
+		}
+		result.Problems = append(result.Problems, problem)
+	}
+
+	// Native frames point at a library we can blame; VM frames are always in
+	// the JVM's own library (jvm.dll, libjvm.so)
+	var known nativeCrash
+	var ok bool
+	switch p.frameType {
+	case "C":
+		known, ok = nativeLibraries[strings.ToLower(p.library)]
+	case "V":
+		known, ok = jvmCrash, true
+	}
+	if ok {
+		message := localize(locale, known.Message)
+		if p.library != "" {
+			message = localize(locale, nativeLibraryText, strings.TrimRight(message, ".。"), p.library)
+		}
+		problem := models.Problem{Severity: "critical", Message: message}
+		for _, s := range known.Solutions {
+			problem.Solutions = append(problem.Solutions, models.Solution{Message: localize(locale, s)})
+		}
+		result.Problems = append(result.Problems, problem)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"mclogs-go/internal/models"
+	"regexp"
+	"strings"
+)
+
+// launcherField extracts one piece of information from a launcher log. The
+// first capturing group of Re is the value.
+type launcherField struct {
+	Label string
+	Re    *regexp.Regexp
+	// NextLine takes the value from the line after the match instead, for
+	// launchers that print "Java path is:" and the path below it
+	NextLine bool
+	// Last keeps the last value instead of the first, e.g. for exit codes
+	// when the game was started several times
+	Last bool
+}
+
+const (
+	launcherVersionLabel = "Launcher Version"
+	gameVersionLabel     = "Game Version"
+	javaPathLabel        = "Java Path"
+	javaVersionLabel     = "Java Version"
+	javaArchLabel        = "Java Architecture"
+	jvmArgsLabel         = "JVM Arguments"
+	exitCodeLabel        = "Exit Code"
+)
+
+var exitCodeField = launcherField{
+	Label: exitCodeLabel,
+	Re:    regexp.MustCompile(`(?i)(?:exit(?:ed)? (?:with )?(?:exit )?code|返回值)[:：]?\s*(-?\d+)`),
+	Last:  true,
+}
+
+var (
+	hmclFields = []launcherField{
+		{Label: launcherVersionLabel, Re: regexp.MustCompile(`Hello Minecraft! Launcher (\S+)`)},
+		{Label: gameVersionLabel, Re: regexp.MustCompile(`(?:Launching game version|Game version):? (\S+)`)},
+		{Label: javaPathLabel, Re: regexp.MustCompile(`Java Home: (.+)$`)},
+		{Label: javaVersionLabel, Re: regexp.MustCompile(`Java Version: ([^,\s]+)`)},
+		{Label: javaArchLabel, Re: regexp.MustCompile(`Java Architecture: (\S+)`)},
+		{Label: jvmArgsLabel, Re: regexp.MustCompile(`(?:JVM Arguments|Java Arguments): (.+)$`)},
+		exitCodeField,
+	}
+	pclFields = []launcherField{
+		{Label: launcherVersionLabel, Re: regexp.MustCompile(`(?:程序版本|PCL 版本)[:：]\s*(.+)$`)},
+		{Label: gameVersionLabel, Re: regexp.MustCompile(`(?:Minecraft 版本|游戏版本)[:：]\s*(\S+)`)},
+		{Label: javaPathLabel, Re: regexp.MustCompile(`((?:[A-Za-z]:)?[^,，:：]*javaw?\.exe)`)},
+		{Label: javaVersionLabel, Re: regexp.MustCompile(`(?:选择的 Java|Java 信息)[:：]\s*(?:Java )?(\d[\w.+\-]*)`)},
+		{Label: javaArchLabel, Re: regexp.MustCompile(`(?:选择的 Java|Java 信息)[:：].*\((\d+) ?Bit\)`)},
+		{Label: jvmArgsLabel, Re: regexp.MustCompile(`(?:JVM 参数|启动参数)[:：]\s*(.+)$`)},
+		exitCodeField,
+	}
+	prismFields = []launcherField{
+		{Label: launcherVersionLabel, Re: regexp.MustCompile(`(?:Prism Launcher|PolyMC|MultiMC) version: (.+)$`)},
+		{Label: gameVersionLabel, Re: regexp.MustCompile(`--version (\S+)`)},
+		{Label: javaPathLabel, Re: regexp.MustCompile(`^Java path is:$`), NextLine: true},
+		{Label: javaVersionLabel, Re: regexp.MustCompile(`^Java is version ([^,\s]+)`)},
+		{Label: javaArchLabel, Re: regexp.MustCompile(`^Java is version [^,]+, using (.+?) architecture`)},
+		{Label: jvmArgsLabel, Re: regexp.MustCompile(`^Java Arguments:$`), NextLine: true},
+		exitCodeField,
+	}
+	officialLauncherFields = []launcherField{
+		{Label: launcherVersionLabel, Re: regexp.MustCompile(`Minecraft Launcher (\d+\.\d+\.\d+\S*)`)},
+		{Label: gameVersionLabel, Re: regexp.MustCompile(`(?:Launching|Starting) (?:game|Minecraft)(?: version)? (\d+\.\d+\S*)`)},
+		{Label: javaPathLabel, Re: regexp.MustCompile(`(?:Java executable|Java path)[:=] ?(.+)$`)},
+		{Label: javaVersionLabel, Re: regexp.MustCompile(`(?:Java version|java-runtime-\w+) ?[:(]? ?(\d+[\w.+\-]*)`)},
+		{Label: jvmArgsLabel, Re: regexp.MustCompile(`(?:JVM arguments|Java arguments)[:=] ?(.+)$`)},
+		{
+			Label: exitCodeLabel,
+			Re:    regexp.MustCompile(`Game (?:process )?(?:ended|exited) with (?:bad state \()?(?:exit )?code:? (-?\d+)`),
+			Last:  true,
+		},
+	}
+)
+
+// launcherParser reads the logs written by game launchers. They wrap the
+// game output, so the usual extractors still run alongside it.
+type launcherParser struct {
+	fields []launcherField
+	values map[string]string
+	// field whose value is expected on the next line
+	pending *launcherField
+}
+
+func newLauncherParser(fields []launcherField) *launcherParser {
+	return &launcherParser{fields: fields, values: make(map[string]string)}
+}
+
+func (p *launcherParser) feed(lineNo int, line string) {
+	trimmed := strings.TrimSpace(line)
+
+	if p.pending != nil {
+		if trimmed == "" {
+			return
+		}
+		p.values[p.pending.Label] = trimmed
+		p.pending = nil
+		return
+	}
+
+	for i := range p.fields {
+		field := &p.fields[i]
+		if _, ok := p.values[field.Label]; ok && !field.Last {
+			continue
+		}
+		m := field.Re.FindStringSubmatch(trimmed)
+		if m == nil {
+			continue
+		}
+		if field.NextLine {
+			p.pending = field
+			return
+		}
+		p.values[field.Label] = strings.TrimSpace(m[1])
+	}
+}
+
+func (p *launcherParser) finish(result *models.AnalysisResult) {
+	if v := p.values[gameVersionLabel]; v != "" {
+		result.Version = v
+	}
+	for _, field := range p.fields {
+		if v := p.values[field.Label]; v != "" {
+			result.Information = append(result.Information, models.Info{Label: label(result.Locale, field.Label), Value: v})
+		}
+	}
+}
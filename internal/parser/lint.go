@@ -0,0 +1,288 @@
+package parser
+
+import (
+	"fmt"
+	"mclogs-go/internal/models"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+)
+
+var placeholderRe = regexp.MustCompile(`\$(\d+)`)
+
+// LintIssue is a mistake found in a patterns file. Errors stop the engine
+// from loading or make a rule misbehave; warnings are likely mistakes.
+type LintIssue struct {
+	// Kind is "detector" or "rule"
+	Kind    string
+	Name    string
+	Field   string
+	Message string
+	Warning bool
+}
+
+func (i LintIssue) String() string {
+	level := "error"
+	if i.Warning {
+		level = "warning"
+	}
+	where := fmt.Sprintf("%s %q", i.Kind, i.Name)
+	if i.Field != "" {
+		where += " " + i.Field
+	}
+	return fmt.Sprintf("%s: %s: %s", level, where, i.Message)
+}
+
+type linter struct {
+	issues   []LintIssue
+	fallback string
+}
+
+func (l *linter) add(kind, name, field string, warning bool, format string, args ...any) {
+	l.issues = append(l.issues, LintIssue{
+		Kind:    kind,
+		Name:    name,
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+		Warning: warning,
+	})
+}
+
+// compile reports an invalid regular expression and returns nil for it.
+func (l *linter) compile(kind, name, field, pattern string) *regexp.Regexp {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		l.add(kind, name, field, false, "invalid regex: %v", err)
+	}
+	return re
+}
+
+// compileLog is compile for a pattern matched against the log.
+func (l *linter) compileLog(kind, name, field, pattern string) *regexp.Regexp {
+	re := l.compile(kind, name, field, pattern)
+	// AnalyzeReader matches line by line, where $ is the end of every line
+	if parsed, err := syntax.Parse(pattern, syntax.Perl); err == nil && containsOp(parsed, syntax.OpEndText) {
+		l.add(kind, name, field, true, "$ outside (?m) only matches at the end of the log, use (?m)$ for the end of a line")
+	}
+	return re
+}
+
+func containsOp(re *syntax.Regexp, op syntax.Op) bool {
+	if re.Op == op {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if containsOp(sub, op) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks a patterns file for invalid regexes, placeholders without a
+// matching capture group, unused capture groups, duplicate names and
+// missing fixtures. Unlike NewEngine it reports every problem it finds.
+func Lint(config *models.PatternConfig) []LintIssue {
+	l := &linter{fallback: config.FallbackLocale}
+	if l.fallback == "" {
+		l.fallback = defaultLocale
+	}
+
+	names := make(map[string]bool)
+	types := make(map[string]bool)
+	parents := make(map[string]string)
+	for _, det := range config.Detectors {
+		if names[det.Name] {
+			l.add("detector", det.Name, "", false, "duplicate name")
+		}
+		names[det.Name] = true
+		if types[det.Type] {
+			l.add("detector", det.Name, "", true, "type %q is already used by another detector", det.Type)
+		}
+		types[det.Type] = true
+		if det.Parent != "" {
+			parents[det.Type] = det.Parent
+		}
+		l.detector(det)
+	}
+	cyclic := make(map[string]bool)
+	for _, det := range config.Detectors {
+		if cyclic[det.Type] {
+			continue
+		}
+		seen := []string{det.Type}
+		for p := parents[det.Type]; p != ""; p = parents[p] {
+			if slices.Contains(seen, p) {
+				l.add("detector", det.Name, "parent", false, "parent types form a cycle")
+				cyclic[det.Type] = true
+				break
+			}
+			seen = append(seen, p)
+		}
+	}
+
+	names = make(map[string]bool)
+	for _, rule := range config.Analyzers {
+		if names[rule.Name] {
+			l.add("rule", rule.Name, "", false, "duplicate name")
+		}
+		names[rule.Name] = true
+		l.rule(rule)
+	}
+	return l.issues
+}
+
+func (l *linter) detector(det models.DetectorPattern) {
+	const kind = "detector"
+	ok := true
+	if det.Pattern != "" {
+		ok = l.compileLog(kind, det.Name, "pattern", det.Pattern) != nil && ok
+	}
+	for i, sig := range det.Signals {
+		ok = l.compileLog(kind, det.Name, fmt.Sprintf("signals[%d]", i), sig.Pattern) != nil && ok
+	}
+	if det.Version != "" {
+		if re := l.compileLog(kind, det.Name, "version", det.Version); re != nil && re.NumSubexp() == 0 {
+			l.add(kind, det.Name, "version", false, "regex has no capture group for the version")
+		}
+	}
+	if ok {
+		if _, err := compileDetector(det); err != nil {
+			l.add(kind, det.Name, "", false, "%v", err)
+		}
+	}
+	if len(det.Examples) == 0 {
+		l.add(kind, det.Name, "", true, "no examples")
+	}
+}
+
+func (l *linter) rule(rule models.AnalyzerRule) {
+	const kind = "rule"
+	ok := true
+	check := func(field, pattern string) {
+		ok = l.compileLog(kind, rule.Name, field, pattern) != nil && ok
+	}
+	if rule.Pattern != "" {
+		check("pattern", rule.Pattern)
+	}
+	for i, p := range rule.AllOf {
+		check(fmt.Sprintf("all_of[%d]", i), p)
+	}
+	for i, p := range rule.AnyOf {
+		check(fmt.Sprintf("any_of[%d]", i), p)
+	}
+	for i, p := range rule.NoneOf {
+		check(fmt.Sprintf("none_of[%d]", i), p)
+	}
+	if rule.Exception != "" {
+		// matched against exception class names, not the log
+		ok = l.compile(kind, rule.Name, "exception", rule.Exception) != nil && ok
+	}
+	if rule.Versions != "" {
+		if err := checkVersionRange(rule.Versions); err != nil {
+			l.add(kind, rule.Name, "versions", false, "%v", err)
+			ok = false
+		}
+	}
+	for i, mod := range rule.Mods {
+		if mod.Version == "" {
+			continue
+		}
+		if err := checkVersionRange(mod.Version); err != nil {
+			l.add(kind, rule.Name, fmt.Sprintf("mods[%d].version", i), false, "%v", err)
+			ok = false
+		}
+	}
+	if len(rule.Examples) == 0 {
+		l.add(kind, rule.Name, "", true, "no examples")
+	}
+	if !ok {
+		return
+	}
+
+	r, err := compileRule(rule)
+	if err != nil {
+		l.add(kind, rule.Name, "", false, "%v", err)
+		return
+	}
+
+	groups, fromRegex := r.groupCount()
+	used := make(map[int]bool)
+	checkText := func(field, text string) {
+		for _, m := range placeholderRe.FindAllStringSubmatch(text, -1) {
+			n, _ := strconv.Atoi(m[1])
+			used[n] = true
+			if n == 0 || n > groups {
+				l.add(kind, rule.Name, field, false, "placeholder $%d has no matching group (the rule provides %d)", n, groups)
+			}
+		}
+	}
+	checkLocalized := func(field string, text models.LocalizedText) {
+		for locale, s := range text {
+			f := field
+			if locale != "" {
+				f += "[" + locale + "]"
+			}
+			checkText(f, s)
+		}
+		if _, ok := text[""]; !ok && len(text) > 0 && text[l.fallback] == "" {
+			l.add(kind, rule.Name, field, true, "no %q text to fall back to", l.fallback)
+		}
+	}
+	checkLocalized("message", rule.Message)
+	for i, s := range rule.Solutions {
+		checkLocalized(fmt.Sprintf("solutions[%d].message", i), s.Message)
+		if a := s.Action; a != nil {
+			field := fmt.Sprintf("solutions[%d].action", i)
+			if len(a.Label) > 0 {
+				checkLocalized(field+".label", a.Label)
+			}
+			for _, f := range []struct{ name, value string }{
+				{"url", a.URL}, {"file", a.File}, {"key", a.Key}, {"value", a.Value},
+				{"mod", a.Mod}, {"plugin", a.Plugin}, {"flag", a.Flag},
+			} {
+				checkText(field+"."+f.name, f.value)
+			}
+		}
+	}
+
+	if !fromRegex {
+		return
+	}
+	for n := 1; n <= groups; n++ {
+		if !used[n] {
+			l.add(kind, rule.Name, "", true, "capture group %d is never used, make it non-capturing with (?:...)", n)
+		}
+	}
+}
+
+// groupCount returns how many placeholders the rule can fill, following the
+// same order as match, and whether they come from a regex rather than from
+// mod versions.
+func (r *compiledRule) groupCount() (int, bool) {
+	switch {
+	case r.pattern != nil:
+		return r.pattern.NumSubexp(), true
+	case len(r.allOf) > 0:
+		return r.allOf[0].NumSubexp(), true
+	case len(r.anyOf) > 0:
+		// Any of them may be the one that matched
+		n := 0
+		for _, re := range r.anyOf {
+			n = max(n, re.NumSubexp())
+		}
+		return n, true
+	case len(r.Mods) > 0:
+		n := 0
+		for _, mod := range r.Mods {
+			if !mod.Absent {
+				n++
+			}
+		}
+		return n, false
+	case r.exception != nil:
+		return r.exception.NumSubexp(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"mclogs-go/internal/models"
+	"strings"
+)
+
+// crashTypes are the log types written when the game dies. Their root cause
+// is the crash itself, while other logs may only show unrelated errors.
+var crashTypes = map[string]bool{
+	"crash-report": true,
+	"hs-err":       true,
+}
+
+// Merge combines the analyses of related logs, e.g. latest.log and the crash
+// report it ended in, given in upload order. Information, problems, mods and
+// plugins are joined without duplicates; the type and the root cause come
+// from a crash report if there is one. Analyze's "unknown" placeholders
+// only stay when no log had a known type or version. The results are not
+// modified.
+func Merge(results ...*models.AnalysisResult) *models.AnalysisResult {
+	merged := &models.AnalysisResult{
+		Information: []models.Info{},
+		Problems:    []models.Problem{},
+	}
+
+	seenInfo := map[models.Info]bool{}
+	seenProblems := map[string]bool{}
+	mods := map[string]int{}
+	plugins := map[string]int{}
+	rootFromCrash := false
+
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		if known(r.Type) && (merged.Type == "" || crashTypes[r.Type] && !crashTypes[merged.Type]) {
+			merged.Type, merged.Name = r.Type, r.Name
+			merged.Confidence = r.Confidence
+		}
+		if merged.Version == "" && known(r.Version) {
+			merged.Version = r.Version
+		}
+		if merged.Locale == "" {
+			merged.Locale = r.Locale
+		}
+
+		for _, info := range r.Information {
+			if !seenInfo[info] {
+				seenInfo[info] = true
+				merged.Information = append(merged.Information, info)
+			}
+		}
+		for _, p := range r.Problems {
+			if !seenProblems[p.Message] {
+				seenProblems[p.Message] = true
+				merged.Problems = append(merged.Problems, p)
+			}
+		}
+
+		for _, m := range r.Mods {
+			key := modKey(m)
+			if i, ok := mods[key]; ok {
+				merged.Mods[i] = fillMod(merged.Mods[i], m)
+				continue
+			}
+			mods[key] = len(merged.Mods)
+			merged.Mods = append(merged.Mods, m)
+		}
+		for _, p := range r.Plugins {
+			key := strings.ToLower(p.Name)
+			if i, ok := plugins[key]; ok {
+				// the log that saw it fail knows more
+				if p.Status == "failed" && merged.Plugins[i].Status != "failed" {
+					merged.Plugins[i] = p
+				}
+				continue
+			}
+			plugins[key] = len(merged.Plugins)
+			merged.Plugins = append(merged.Plugins, p)
+		}
+
+		merged.Exceptions = append(merged.Exceptions, r.Exceptions...)
+		if r.RootCause != nil && (merged.RootCause == nil || crashTypes[r.Type] && !rootFromCrash) {
+			merged.RootCause = r.RootCause
+			rootFromCrash = crashTypes[r.Type]
+		}
+	}
+
+	if merged.Type == "" {
+		merged.Type, merged.Name = "unknown", "Unknown Log"
+	}
+	if merged.Version == "" {
+		merged.Version = "unknown"
+	}
+	return merged
+}
+
+// known reports whether a type or version was detected, rather than left
+// empty or at Analyze's placeholder.
+func known(s string) bool {
+	return s != "" && s != "unknown"
+}
+
+// modKey identifies a mod across logs, which may know it by ID, name or
+// only by its file.
+func modKey(m models.Mod) string {
+	switch {
+	case m.ID != "":
+		return "id:" + strings.ToLower(m.ID)
+	case m.Name != "":
+		return "name:" + strings.ToLower(m.Name)
+	default:
+		return "file:" + strings.ToLower(m.File)
+	}
+}
+
+// fillMod fills the empty fields of a mod from another sighting of it.
+func fillMod(m, other models.Mod) models.Mod {
+	if m.Name == "" {
+		m.Name = other.Name
+	}
+	if m.Version == "" {
+		m.Version = other.Version
+	}
+	if m.File == "" {
+		m.File = other.File
+	}
+	return m
+}
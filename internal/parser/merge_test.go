@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"mclogs-go/internal/models"
+	"os"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	e := loadEngine(t)
+	analyze := func(name string) *models.AnalysisResult {
+		content, err := os.ReadFile("testdata/corpus/" + name + ".log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e.Analyze(string(content), "")
+	}
+
+	launcher := analyze("prism-fabric")
+	latest := analyze("fabric-optifabric")
+	crash := analyze("crash-report")
+	merged := Merge(launcher, latest, crash)
+
+	if merged.Type != crash.Type {
+		t.Errorf("type = %q, want the crash report's %q", merged.Type, crash.Type)
+	}
+	if noCrash := Merge(launcher, latest); noCrash.Type != launcher.Type {
+		t.Errorf("type without a crash report = %q, want the first file's %q", noCrash.Type, launcher.Type)
+	}
+	// the launcher log has an exception of its own, but the crash report's
+	// is what killed the game
+	if merged.RootCause != crash.RootCause {
+		t.Errorf("root cause = %v, want the crash report's %v", merged.RootCause, crash.RootCause)
+	}
+	if got, want := len(merged.Exceptions), len(launcher.Exceptions)+len(latest.Exceptions)+len(crash.Exceptions); got != want {
+		t.Errorf("%d exceptions, want %d", got, want)
+	}
+
+	ids := map[string]int{}
+	for _, m := range merged.Mods {
+		ids[m.ID]++
+	}
+	for _, m := range append(latest.Mods, crash.Mods...) {
+		if ids[m.ID] != 1 {
+			t.Errorf("mod %q appears %d times in the merged mods", m.ID, ids[m.ID])
+		}
+	}
+
+	problems := map[string]bool{}
+	for _, p := range merged.Problems {
+		if problems[p.Message] {
+			t.Errorf("problem %q appears twice", p.Message)
+		}
+		problems[p.Message] = true
+	}
+	for _, r := range []*models.AnalysisResult{launcher, latest, crash} {
+		for _, p := range r.Problems {
+			if !problems[p.Message] {
+				t.Errorf("problem %q is missing", p.Message)
+			}
+		}
+	}
+
+	// debug.log is often not recognized; the next file's type still counts
+	unknown := e.Analyze("nothing to see here", "")
+	if partly := Merge(unknown, latest); partly.Type != latest.Type || partly.Name != latest.Name || partly.Version != latest.Version {
+		t.Errorf("merged an unknown log and %s %s into %s %s %s", latest.Type, latest.Version, partly.Type, partly.Name, partly.Version)
+	}
+	if none := Merge(unknown, unknown); none.Type != "unknown" || none.Version != "unknown" {
+		t.Errorf("merging unknown logs gave type %q, version %q; want unknown", none.Type, none.Version)
+	}
+
+	if twice := Merge(latest, latest); len(twice.Mods) != len(latest.Mods) || len(twice.Information) != len(latest.Information) {
+		t.Errorf("merging a log with itself changed its mods or information")
+	}
+}
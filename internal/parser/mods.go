@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"mclogs-go/internal/models"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Fabric and Quilt: "Loading 57 mods:"
+	loadingModsRe = regexp.MustCompile(`Loading \d+ mods:\s*$`)
+	// Fabric: "	- fabric-api 0.86.1+1.20.1", nested jars use "|--" or "\--"
+	fabricModRe    = regexp.MustCompile(`^\s+- (\S+) (\S+)`)
+	fabricNestedRe = regexp.MustCompile(`^\s+[|\\]--`)
+	// Fabric/Quilt crash reports: "		fabric-api: Fabric API 0.86.1+1.20.1"
+	crashModRe = regexp.MustCompile(`^\s+([a-z0-9_.\-]+): (.+) (\S+)$`)
+	// Forge/NeoForge crash reports:
+	// "		forge-47.1.0-universal.jar |Forge |forge |47.1.0 |DONE |Manifest: ..."
+	forgeModRowRe = regexp.MustCompile(`^\s*(\S[^|]*?\.jar)\s*\|([^|]*)\|([^|]*)\|([^|]*)\|`)
+	// Forge 1.12 crash reports: "	| LCHIJAAAA | minecraft | 1.12.2 | minecraft.jar | None |"
+	fmlStateRowRe = regexp.MustCompile(`^\s*\|\s*[A-Z]+\s*\|\s*([^|\s]+)\s*\|\s*([^|\s]+)\s*\|\s*([^|]+?)\s*\|`)
+	// Forge/NeoForge mod discovery: "Found mod file sodium.jar of type MOD with ..."
+	forgeModFileRe = regexp.MustCompile(`Found mod file "?([^"\s]+\.jar)"? of type MOD`)
+)
+
+// maxMods bounds how many mods are kept per log; the largest modpacks load
+// a few hundred.
+const maxMods = 1000
+
+type modListMode int
+
+const (
+	modListNone modListMode = iota
+	modListLoading
+	modListQuiltTable
+	modListCrash
+)
+
+// modListParser collects the loaded mods from Fabric, Quilt, Forge and
+// NeoForge output, both in regular logs and in crash reports.
+type modListParser struct {
+	mode  modListMode
+	mods  []models.Mod
+	index map[string]int
+
+	// column positions of the Quilt table, by lower-cased header name
+	quiltColumns map[string]int
+}
+
+func (p *modListParser) feed(lineNo int, line string) {
+	trimmed := strings.TrimSpace(line)
+
+	switch p.mode {
+	case modListLoading:
+		if fabricNestedRe.MatchString(line) {
+			return
+		}
+		if strings.HasPrefix(trimmed, "|") {
+			p.mode = modListQuiltTable
+			p.quiltColumns = nil
+			p.feedQuiltRow(trimmed)
+			return
+		}
+		if m := fabricModRe.FindStringSubmatch(line); m != nil {
+			p.add(models.Mod{ID: m[1], Version: m[2]})
+			return
+		}
+		p.mode = modListNone
+	case modListQuiltTable:
+		if strings.HasPrefix(trimmed, "|") {
+			p.feedQuiltRow(trimmed)
+			return
+		}
+		p.mode = modListNone
+	case modListCrash:
+		if m := crashModRe.FindStringSubmatch(line); m != nil && indentWidth(line) > indentWidth("\t") {
+			p.add(models.Mod{ID: m[1], Name: m[2], Version: m[3]})
+			return
+		}
+		p.mode = modListNone
+	}
+
+	switch {
+	case loadingModsRe.MatchString(line):
+		p.mode = modListLoading
+	case trimmed == "Fabric Mods:" || trimmed == "Quilt Mods:":
+		p.mode = modListCrash
+	}
+
+	if m := forgeModRowRe.FindStringSubmatch(line); m != nil {
+		p.add(models.Mod{
+			File:    strings.TrimSpace(m[1]),
+			Name:    strings.TrimSpace(m[2]),
+			ID:      strings.TrimSpace(m[3]),
+			Version: strings.TrimSpace(m[4]),
+		})
+	} else if m := fmlStateRowRe.FindStringSubmatch(line); m != nil {
+		p.add(models.Mod{ID: m[1], Version: m[2], File: m[3]})
+	} else if m := forgeModFileRe.FindStringSubmatch(line); m != nil {
+		p.add(models.Mod{File: m[1]})
+	}
+}
+
+func (p *modListParser) feedQuiltRow(row string) {
+	cells := strings.Split(strings.Trim(row, "|"), "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+
+	if p.quiltColumns == nil {
+		p.quiltColumns = make(map[string]int)
+		for i, name := range cells {
+			p.quiltColumns[strings.ToLower(name)] = i
+		}
+		return
+	}
+	// separator row, e.g. "|------:|-----|"
+	if strings.Trim(strings.Join(cells, ""), "-:") == "" {
+		return
+	}
+
+	cell := func(name string) string {
+		if i, ok := p.quiltColumns[name]; ok && i < len(cells) {
+			return cells[i]
+		}
+		return ""
+	}
+	p.add(models.Mod{ID: cell("id"), Name: cell("name"), Version: cell("version"), File: cell("file(s)")})
+}
+
+// add records a mod, merging it into an earlier entry with the same ID or
+// file so a log that also embeds a crash report does not list mods twice.
+func (p *modListParser) add(mod models.Mod) {
+	if p.index == nil {
+		p.index = make(map[string]int)
+	}
+
+	var keys []string
+	if mod.ID != "" {
+		keys = append(keys, "id:"+mod.ID)
+	}
+	if mod.File != "" {
+		keys = append(keys, "file:"+mod.File)
+	}
+
+	for _, key := range keys {
+		if i, ok := p.index[key]; ok {
+			existing := &p.mods[i]
+			existing.ID = firstNonEmpty(existing.ID, mod.ID)
+			existing.Name = firstNonEmpty(existing.Name, mod.Name)
+			existing.Version = firstNonEmpty(existing.Version, mod.Version)
+			existing.File = firstNonEmpty(existing.File, mod.File)
+			for _, k := range keys {
+				p.index[k] = i
+			}
+			return
+		}
+	}
+
+	if len(p.mods) >= maxMods {
+		return
+	}
+	p.mods = append(p.mods, mod)
+	for _, key := range keys {
+		p.index[key] = len(p.mods) - 1
+	}
+}
+
+func (p *modListParser) finish(result *models.AnalysisResult) {
+	result.Mods = p.mods
+}
+
+// findMod looks a mod up by ID, ignoring case.
+func findMod(mods []models.Mod, id string) *models.Mod {
+	for i := range mods {
+		if strings.EqualFold(mods[i].ID, id) {
+			return &mods[i]
+		}
+	}
+	return nil
+}
+
+// matchMods checks a rule's mod conditions and returns the versions of the
+// mods that had to be present, in condition order.
+func matchMods(conditions []models.ModCondition, mods []models.Mod) ([]string, bool) {
+	var versions []string
+	for _, cond := range conditions {
+		mod := findMod(mods, cond.ID)
+		if cond.Absent {
+			if mod != nil {
+				return nil, false
+			}
+			continue
+		}
+		if mod == nil {
+			return nil, false
+		}
+		if cond.Version != "" && !matchVersionRange(mod.Version, cond.Version) {
+			return nil, false
+		}
+		versions = append(versions, mod.Version)
+	}
+	return versions, true
+}
@@ -1,11 +1,18 @@
 package parser
 
 import (
+	"context"
+	"io"
+	"log"
 	"mclogs-go/internal/models"
+	"os"
+	"sync/atomic"
+	"time"
 )
 
 type Parser struct {
-	engine *Engine
+	path   string
+	engine atomic.Pointer[Engine]
 }
 
 func NewParser(patternsPath string) (*Parser, error) {
@@ -13,9 +20,75 @@ func NewParser(patternsPath string) (*Parser, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Parser{engine: engine}, nil
+	p := &Parser{path: patternsPath}
+	p.engine.Store(engine)
+	return p, nil
 }
 
-func (p *Parser) Parse(content string) *models.AnalysisResult {
-	return p.engine.Analyze(content)
+// Parse analyzes a log with the problems written in locale, see
+// Engine.Analyze.
+func (p *Parser) Parse(content, locale string) *models.AnalysisResult {
+	return p.engine.Load().Analyze(content, locale)
+}
+
+// ParseReader analyzes a log read from r line by line, see
+// Engine.AnalyzeReader.
+func (p *Parser) ParseReader(r io.Reader, locale string) (*models.AnalysisResult, error) {
+	return p.engine.Load().AnalyzeReader(r, locale)
+}
+
+// Locales returns the locales problems can be written in, the fallback first.
+func (p *Parser) Locales() []string {
+	return p.engine.Load().Locales()
+}
+
+// Version identifies the rule set currently in use, see Engine.Version.
+func (p *Parser) Version() string {
+	return p.engine.Load().Version()
+}
+
+// Reload rebuilds the engine from the patterns file. It reports whether the
+// rule set changed; on error the current engine stays in use.
+func (p *Parser) Reload() (bool, error) {
+	engine, err := NewEngine(p.path)
+	if err != nil {
+		return false, err
+	}
+	if engine.Version() == p.Version() {
+		return false, nil
+	}
+	p.engine.Store(engine)
+	return true, nil
+}
+
+// Watch checks the patterns file every interval and reloads it when it was
+// modified, until ctx is done.
+func (p *Parser) Watch(ctx context.Context, interval time.Duration) {
+	var last os.FileInfo
+	if fi, err := os.Stat(p.path); err == nil {
+		last = fi
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		fi, err := os.Stat(p.path)
+		if err != nil || last != nil && fi.ModTime().Equal(last.ModTime()) && fi.Size() == last.Size() {
+			continue
+		}
+		last = fi
+
+		changed, err := p.Reload()
+		if err != nil {
+			log.Printf("[Parser] Keeping rule set %s, reload failed: %v", p.Version(), err)
+		} else if changed {
+			log.Printf("[Parser] Reloaded %s, rule set %s", p.path, p.Version())
+		}
+	}
 }
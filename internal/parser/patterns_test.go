@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const patternsFile = "../../configs/patterns.yaml"
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/corpus")
+
+func loadEngine(t *testing.T) *Engine {
+	t.Helper()
+	e, err := NewEngine(patternsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestPatternFixtures(t *testing.T) {
+	e := loadEngine(t)
+
+	for _, det := range e.detectors {
+		if len(det.Examples) == 0 {
+			t.Errorf("detector %q has no examples", det.Name)
+		}
+	}
+	for _, rule := range e.rules {
+		if len(rule.Examples) == 0 {
+			t.Errorf("rule %q has no examples", rule.Name)
+		}
+	}
+	for _, f := range e.CheckFixtures() {
+		t.Error(f.Error())
+	}
+}
+
+// TestCorpus analyzes every testdata/corpus/*.log and compares the result
+// with the .json next to it. Run with -update after an intended change.
+func TestCorpus(t *testing.T) {
+	e := loadEngine(t)
+
+	logs, err := filepath.Glob("testdata/corpus/*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) == 0 {
+		t.Fatal("no logs in testdata/corpus")
+	}
+
+	for _, path := range logs {
+		name := strings.TrimSuffix(filepath.Base(path), ".log")
+		t.Run(name, func(t *testing.T) {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(e.Analyze(string(content), ""), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := strings.TrimSuffix(path, ".log") + ".json"
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("insights differ from %s (run with -update if intended)\ngot:\n%s", golden, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"mclogs-go/internal/models"
+	"regexp"
+	"strings"
+)
+
+const (
+	pluginLoaded  = "loaded"
+	pluginEnabled = "enabled"
+	pluginFailed  = "failed"
+)
+
+var (
+	// Bukkit/Spigot: "[LuckPerms] Loading LuckPerms v5.4.102"
+	// Paper/Folia:   "[LuckPerms] Loading server plugin LuckPerms v5.4.102"
+	bukkitLoadingRe   = regexp.MustCompile(`\[[^\]\s]+\] Loading (?:server plugin )?(\S+) v(\S+)`)
+	bukkitEnablingRe  = regexp.MustCompile(`\[[^\]\s]+\] Enabling (\S+) v(\S+)`)
+	bukkitEnableErrRe = regexp.MustCompile(`Error occurred while enabling (\S+) v(\S+)`)
+	bukkitLoadErrRe   = regexp.MustCompile(`Could not load (?:plugin )?'(?:plugins[\\/])?([^']+?)'`)
+	// Velocity: "Loaded plugin luckperms 5.4.102 by Luck"
+	// BungeeCord: "Loaded plugin LuckPerms version 5.4.102 by Luck"
+	proxyLoadedRe  = regexp.MustCompile(`Loaded plugin (\S+) (?:version )?(\S+) by `)
+	proxyEnabledRe = regexp.MustCompile(`Enabled plugin (\S+) version (\S+) by `)
+	proxyLoadErrRe = regexp.MustCompile(`(?:Exception encountered when loading plugin:|Can't create plugin|Unable to load plugin|Error enabling plugin) (\S+)`)
+)
+
+// maxPlugins bounds how many plugins, and failures of them, are kept per
+// log.
+const maxPlugins = 500
+
+type pluginFailure struct {
+	index  int
+	lineNo int
+	enable bool
+}
+
+// pluginListParser tracks plugin startup on Bukkit-family servers and on
+// the Velocity and BungeeCord proxies.
+type pluginListParser struct {
+	plugins  []models.Plugin
+	failures []pluginFailure
+}
+
+func (p *pluginListParser) feed(lineNo int, line string) {
+	if m := bukkitEnableErrRe.FindStringSubmatch(line); m != nil {
+		p.fail(lineNo, true, models.Plugin{Name: m[1], Version: m[2]})
+	} else if m := bukkitLoadErrRe.FindStringSubmatch(line); m != nil {
+		p.fail(lineNo, false, models.Plugin{Name: strings.TrimSuffix(m[1], ".jar"), File: m[1]})
+	} else if m := proxyLoadErrRe.FindStringSubmatch(line); m != nil {
+		if file := pathBase(m[1]); strings.HasSuffix(file, ".jar") {
+			p.fail(lineNo, false, models.Plugin{Name: strings.TrimSuffix(file, ".jar"), File: file})
+		} else {
+			p.fail(lineNo, strings.Contains(line, "Error enabling"), models.Plugin{Name: m[1]})
+		}
+	} else if m := bukkitEnablingRe.FindStringSubmatch(line); m != nil {
+		p.set(models.Plugin{Name: m[1], Version: m[2], Status: pluginEnabled})
+	} else if m := proxyEnabledRe.FindStringSubmatch(line); m != nil {
+		p.set(models.Plugin{Name: m[1], Version: m[2], Status: pluginEnabled})
+	} else if m := bukkitLoadingRe.FindStringSubmatch(line); m != nil {
+		p.set(models.Plugin{Name: m[1], Version: m[2], Status: pluginLoaded})
+	} else if m := proxyLoadedRe.FindStringSubmatch(line); m != nil {
+		p.set(models.Plugin{Name: m[1], Version: m[2], Status: pluginLoaded})
+	}
+}
+
+// set records a plugin or advances its status. A failed plugin stays failed.
+// It returns the plugin's index, or -1 when there are too many plugins.
+func (p *pluginListParser) set(plugin models.Plugin) int {
+	for i := range p.plugins {
+		existing := &p.plugins[i]
+		if !strings.EqualFold(existing.Name, plugin.Name) {
+			continue
+		}
+		existing.Version = firstNonEmpty(existing.Version, plugin.Version)
+		existing.File = firstNonEmpty(existing.File, plugin.File)
+		if existing.Status != pluginFailed {
+			existing.Status = plugin.Status
+		}
+		return i
+	}
+	if len(p.plugins) >= maxPlugins {
+		return -1
+	}
+	p.plugins = append(p.plugins, plugin)
+	return len(p.plugins) - 1
+}
+
+func (p *pluginListParser) fail(lineNo int, enable bool, plugin models.Plugin) {
+	plugin.Status = pluginFailed
+	i := p.set(plugin)
+	if i < 0 || len(p.failures) >= maxPlugins {
+		return
+	}
+	p.failures = append(p.failures, pluginFailure{index: i, lineNo: lineNo, enable: enable})
+}
+
+func (p *pluginListParser) finish(result *models.AnalysisResult) {
+	for _, f := range p.failures {
+		plugin := &p.plugins[f.index]
+		// The stack trace explaining the failure starts right below
+		if ex := exceptionNear(result.Exceptions, f.lineNo, 2); ex != nil {
+			plugin.Error = ex.RootCause().String()
+		}
+		if !f.enable {
+			// Load failures are reported by the "Plugin Load Failure" rule
+			continue
+		}
+
+		problem := models.Problem{
+			Severity: "error",
+			Message:  localize(result.Locale, pluginFailedText, plugin.Name),
+			Solutions: []models.Solution{
+				{Message: localize(result.Locale, pluginSupportText, plugin.Name, plugin.Version)},
+			},
+		}
+		if plugin.Error != "" {
+			problem.Message = localize(result.Locale, pluginFailedWithErrorText, plugin.Name, plugin.Error)
+		}
+		result.Problems = append(result.Problems, problem)
+	}
+
+	result.Plugins = p.plugins
+}
+
+// exceptionNear returns the first exception starting within maxDistance
+// lines after lineNo.
+func exceptionNear(exceptions []*models.JavaException, lineNo, maxDistance int) *models.JavaException {
+	for _, ex := range exceptions {
+		if ex.Line > lineNo && ex.Line <= lineNo+maxDistance {
+			return ex
+		}
+	}
+	return nil
+}
+
+func pathBase(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
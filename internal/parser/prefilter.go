@@ -0,0 +1,301 @@
+package parser
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minLiteralLen is the shortest literal worth looking for; shorter ones are
+// in almost every log.
+const minLiteralLen = 3
+
+// prefilter rules out regexes before they run. At load time it takes from
+// every regex the literals one of which each match must contain, and puts
+// all of them into a single Aho-Corasick automaton. One scan of a log then
+// tells which regexes can match at all.
+type prefilter struct {
+	matcher *literalMatcher
+	// literals of each regex, one of which must be found for it to match;
+	// regexes without a literal worth looking for are not in the map
+	needs map[*regexp.Regexp][]int
+}
+
+func newPrefilter(res []*regexp.Regexp) *prefilter {
+	p := &prefilter{needs: make(map[*regexp.Regexp][]int)}
+	ids := make(map[string]int)
+	var literals []string
+	for _, re := range res {
+		if _, done := p.needs[re]; done || re == nil {
+			continue
+		}
+		parsed, err := syntax.Parse(re.String(), syntax.Perl)
+		if err != nil {
+			continue
+		}
+		lits := requiredLiterals(parsed.Simplify())
+		if len(lits) == 0 {
+			continue
+		}
+		need := make([]int, 0, len(lits))
+		for _, lit := range lits {
+			id, ok := ids[lit]
+			if !ok {
+				id = len(literals)
+				ids[lit] = id
+				literals = append(literals, lit)
+			}
+			need = append(need, id)
+		}
+		p.needs[re] = need
+	}
+	p.matcher = newLiteralMatcher(literals)
+	return p
+}
+
+// scan returns which literals are in text.
+func (p *prefilter) scan(text string) []bool {
+	found := make([]bool, len(p.matcher.literals))
+	p.matcher.scan(text, found)
+	return found
+}
+
+// mayMatch reports whether re can match a text with the found literals. A
+// nil prefilter or found lets everything through.
+func (p *prefilter) mayMatch(re *regexp.Regexp, found []bool) bool {
+	if p == nil || found == nil {
+		return true
+	}
+	need, ok := p.needs[re]
+	return !ok || anyFound(need, found)
+}
+
+func anyFound(need []int, found []bool) bool {
+	for _, id := range need {
+		if found[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredLiterals returns lowercased strings of which every match of re
+// contains at least one, or nil if there are none of minLiteralLen or more.
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return literalPieces(re)
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return requiredLiterals(re.Sub[0])
+		}
+	case syntax.OpAlternate:
+		var all []string
+		for _, sub := range re.Sub {
+			lits := requiredLiterals(sub)
+			if lits == nil {
+				return nil
+			}
+			all = append(all, lits...)
+		}
+		return all
+	case syntax.OpConcat:
+		// adjacent literals, even with different flags, make one string
+		var best []string
+		var run strings.Builder
+		flush := func() {
+			if run.Len() >= minLiteralLen {
+				best = betterLiterals(best, []string{run.String()})
+			}
+			run.Reset()
+		}
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral && !hasFoldingRune(sub) {
+				run.WriteString(lowerASCII(string(sub.Rune)))
+				continue
+			}
+			flush()
+			best = betterLiterals(best, requiredLiterals(sub))
+		}
+		flush()
+		return best
+	}
+	return nil
+}
+
+// literalPieces lowercases a literal. Under (?i), letters that fold outside
+// ASCII, say Cyrillic, cannot be matched against the ASCII-lowercased log,
+// so the literal is cut there and the longest piece kept.
+func literalPieces(re *syntax.Regexp) []string {
+	var best string
+	var piece strings.Builder
+	for _, r := range re.Rune {
+		if re.Flags&syntax.FoldCase != 0 && foldsOutsideASCII(r) {
+			piece.Reset()
+			continue
+		}
+		piece.WriteString(lowerASCII(string(r)))
+		if piece.Len() > len(best) {
+			best = piece.String()
+		}
+	}
+	if len(best) < minLiteralLen {
+		return nil
+	}
+	return []string{best}
+}
+
+func hasFoldingRune(re *syntax.Regexp) bool {
+	if re.Flags&syntax.FoldCase == 0 {
+		return false
+	}
+	for _, r := range re.Rune {
+		if foldsOutsideASCII(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// foldsOutsideASCII reports whether a rune has a case variant that
+// lowerASCII does not map to it. The scanner handles the two non-ASCII
+// variants of ASCII letters, K (Kelvin) and ſ (long s), itself.
+func foldsOutsideASCII(r rune) bool {
+	return r >= utf8.RuneSelf && unicode.SimpleFold(r) != r
+}
+
+// betterLiterals picks the more selective of two literal sets: the one whose
+// shortest literal is longer.
+func betterLiterals(a, b []string) []string {
+	if b == nil {
+		return a
+	}
+	if a == nil {
+		return b
+	}
+	if shortest(b) > shortest(a) || shortest(b) == shortest(a) && len(b) < len(a) {
+		return b
+	}
+	return a
+}
+
+func shortest(lits []string) int {
+	n := len(lits[0])
+	for _, l := range lits[1:] {
+		n = min(n, len(l))
+	}
+	return n
+}
+
+func lowerASCII(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+// literalMatcher finds many literals in a single pass: an Aho-Corasick
+// automaton over ASCII-lowercased bytes, compiled to a full transition
+// table.
+type literalMatcher struct {
+	literals []string
+	// bytes that appear in no literal share class 0
+	classes  [256]int32
+	nclasses int32
+	// next state for state*nclasses+class
+	next []int32
+	// literals ending in each state
+	out [][]int
+}
+
+func newLiteralMatcher(literals []string) *literalMatcher {
+	m := &literalMatcher{literals: literals, nclasses: 1}
+	for _, lit := range literals {
+		for i := 0; i < len(lit); i++ {
+			if m.classes[lit[i]] == 0 {
+				m.classes[lit[i]] = m.nclasses
+				m.nclasses++
+			}
+		}
+	}
+
+	// trie, with -1 for missing edges
+	newState := func() int32 {
+		for range m.nclasses {
+			m.next = append(m.next, -1)
+		}
+		m.out = append(m.out, nil)
+		return int32(len(m.out) - 1)
+	}
+	newState()
+	for id, lit := range literals {
+		var s int32
+		for i := 0; i < len(lit); i++ {
+			edge := s*m.nclasses + m.classes[lit[i]]
+			if m.next[edge] < 0 {
+				t := newState()
+				m.next[edge] = t
+			}
+			s = m.next[edge]
+		}
+		m.out[s] = append(m.out[s], id)
+	}
+
+	// breadth first, take missing edges and extra output from the failure
+	// link, which is always closer to the root
+	fail := make([]int32, len(m.out))
+	var queue []int32
+	for c := range m.nclasses {
+		if t := m.next[c]; t > 0 {
+			queue = append(queue, t)
+		} else {
+			m.next[c] = 0
+		}
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		m.out[s] = append(m.out[s], m.out[fail[s]]...)
+		for c := range m.nclasses {
+			edge := s*m.nclasses + c
+			if t := m.next[edge]; t >= 0 {
+				fail[t] = m.next[fail[s]*m.nclasses+c]
+				queue = append(queue, t)
+			} else {
+				m.next[edge] = m.next[fail[s]*m.nclasses+c]
+			}
+		}
+	}
+	return m
+}
+
+// scan marks in found every literal that occurs in text, ignoring ASCII
+// case.
+func (m *literalMatcher) scan(text string, found []bool) {
+	if len(m.literals) == 0 {
+		return
+	}
+	var s int32
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case 'A' <= c && c <= 'Z':
+			c += 'a' - 'A'
+		case c == 0xE2 && strings.HasPrefix(text[i:], "\u212a"):
+			c, i = 'k', i+2
+		case c == 0xC5 && strings.HasPrefix(text[i:], "\u017f"):
+			c, i = 's', i+1
+		}
+		s = m.next[s*m.nclasses+m.classes[c]]
+		for _, id := range m.out[s] {
+			found[id] = true
+		}
+	}
+}
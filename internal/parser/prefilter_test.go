@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRequiredLiterals(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{`java\.lang\.OutOfMemoryError`, []string{"java.lang.outofmemoryerror"}},
+		{`Can't keep up! Is the server overloaded\? Running (\d+)ms`, []string{"can't keep up! is the server overloaded? running "}},
+		{`(?i)failed to bind to port`, []string{"failed to bind to port"}},
+		{`Exit Code: (-?\d+)`, []string{"exit code: "}},
+		{`OutOfMemoryError|Java heap space`, []string{"outofmemoryerror", "java heap space"}},
+		{`(?:net\.fabricmc|org\.quiltmc)\.loader`, []string{"net.fabricmc", "org.quiltmc"}},
+		{`Mod (\S+) requires (\S+)`, []string{" requires "}},
+		{`(?m)^\d+$`, nil},
+		{`ab|abcdef`, nil},
+		{`(?:Error)?Exception`, []string{"exception"}},
+		{`(?i)ошибка загрузки`, nil},
+		{`(?i)ошибка: Mod loading`, []string{": mod loading"}},
+	}
+	for _, tt := range tests {
+		re, err := syntax.Parse(tt.pattern, syntax.Perl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := requiredLiterals(re.Simplify()); !slices.Equal(got, tt.want) {
+			t.Errorf("requiredLiterals(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestLiteralMatcher(t *testing.T) {
+	literals := []string{"he", "she", "his", "hers", "error", "rror:", "exception", "a"}
+	m := newLiteralMatcher(literals)
+	for _, text := range []string{
+		"", "ushers", "ERROR: java.lang.Exception", "his", "h e", "shErrOr:", "aaa",
+		"\u212aelvin", "\u017fhe",
+	} {
+		found := make([]bool, len(literals))
+		m.scan(text, found)
+		folded := strings.NewReplacer("\u212a", "k", "\u017f", "s").Replace(lowerASCII(text))
+		for id, lit := range literals {
+			if want := strings.Contains(folded, lit); found[id] != want {
+				t.Errorf("scan(%q): found %q = %v, want %v", text, lit, found[id], want)
+			}
+		}
+	}
+}
+
+// TestPrefilterNoFalseNegatives checks that every regex matching a corpus
+// line is let through by the prefilter.
+func TestPrefilterNoFalseNegatives(t *testing.T) {
+	e := loadEngine(t)
+	paths, err := filepath.Glob("testdata/corpus/*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range splitLines(string(content)) {
+			found := e.prefilter.scan(line)
+			for _, re := range e.regexes() {
+				if re.MatchString(line) && !e.prefilter.mayMatch(re, found) {
+					t.Errorf("%s: %q matches %q but was filtered out", filepath.Base(path), re, line)
+				}
+			}
+		}
+	}
+}
+
+// engineWithRules loads the patterns file with n extra rules that never
+// match, each with its own literal.
+func engineWithRules(b *testing.B, n int) *Engine {
+	b.Helper()
+	data, err := os.ReadFile(patternsFile)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		b.Fatal(err)
+	}
+	analyzers := doc["analyzers"].([]any)
+	for i := range n {
+		analyzers = append(analyzers, map[string]any{
+			"name":     fmt.Sprintf("Synthetic %d", i),
+			"pattern":  fmt.Sprintf(`SyntheticFailure%04d: (\S+) at line (\d+)`, i),
+			"severity": "error",
+			"message":  "Synthetic failure in $1",
+		})
+	}
+	doc["analyzers"] = analyzers
+	if data, err = yaml.Marshal(doc); err != nil {
+		b.Fatal(err)
+	}
+	path := filepath.Join(b.TempDir(), "patterns.yaml")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		b.Fatal(err)
+	}
+	e, err := NewEngine(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return e
+}
+
+// BenchmarkRuleCount shows how analysis time grows with the number of
+// rules that do not match.
+func BenchmarkRuleCount(b *testing.B) {
+	content := largeLog(b, 1<<20)
+	for _, n := range []int{0, 100, 500} {
+		e := engineWithRules(b, n)
+		b.Run(fmt.Sprintf("extra=%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(content)))
+			for b.Loop() {
+				e.Analyze(content, "")
+			}
+		})
+	}
+}
+
+func BenchmarkPrefilterScan(b *testing.B) {
+	e := loadBenchEngine(b)
+	content := largeLog(b, 8<<20)
+	b.Logf("%d regexes, %d with literals, %d literals", len(e.regexes()), len(e.prefilter.needs), len(e.prefilter.matcher.literals))
+
+	b.SetBytes(int64(len(content)))
+	for b.Loop() {
+		e.prefilter.scan(content)
+	}
+}
@@ -0,0 +1,512 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"mclogs-go/internal/models"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// compiledRule is an AnalyzerRule with its regular expressions compiled.
+type compiledRule struct {
+	models.AnalyzerRule
+	pattern   *regexp.Regexp
+	allOf     []*regexp.Regexp
+	anyOf     []*regexp.Regexp
+	noneOf    []*regexp.Regexp
+	exception *regexp.Regexp
+}
+
+func compileRule(rule models.AnalyzerRule) (*compiledRule, error) {
+	r := &compiledRule{AnalyzerRule: rule}
+
+	if rule.Pattern == "" && len(rule.AllOf) == 0 && len(rule.AnyOf) == 0 &&
+		rule.Exception == "" && rule.FramePackage == "" && len(rule.Mods) == 0 {
+		return nil, errors.New("rule has no conditions")
+	}
+
+	var err error
+	if rule.Pattern != "" {
+		if r.pattern, err = regexp.Compile(rule.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	if r.allOf, err = compileAll(rule.AllOf); err != nil {
+		return nil, fmt.Errorf("invalid all_of pattern: %w", err)
+	}
+	if r.anyOf, err = compileAll(rule.AnyOf); err != nil {
+		return nil, fmt.Errorf("invalid any_of pattern: %w", err)
+	}
+	if r.noneOf, err = compileAll(rule.NoneOf); err != nil {
+		return nil, fmt.Errorf("invalid none_of pattern: %w", err)
+	}
+	if rule.Exception != "" {
+		if r.exception, err = regexp.Compile(rule.Exception); err != nil {
+			return nil, fmt.Errorf("invalid exception pattern: %w", err)
+		}
+	}
+	// a range that can't be read would keep the rule from ever matching
+	if rule.Versions != "" {
+		if err := checkVersionRange(rule.Versions); err != nil {
+			return nil, fmt.Errorf("invalid versions: %w", err)
+		}
+	}
+	for i, mod := range rule.Mods {
+		if mod.Version == "" {
+			continue
+		}
+		if err := checkVersionRange(mod.Version); err != nil {
+			return nil, fmt.Errorf("invalid mods[%d].version: %w", i, err)
+		}
+	}
+	for i, s := range rule.Solutions {
+		if s.Action == nil {
+			continue
+		}
+		if err := checkAction(s.Action); err != nil {
+			return nil, fmt.Errorf("solution %d: %w", i, err)
+		}
+	}
+	return r, nil
+}
+
+// checkAction makes sure an action has the fields its type needs.
+func checkAction(a *models.RuleAction) error {
+	var missing string
+	switch a.Type {
+	case models.ActionLink:
+		if a.URL == "" {
+			missing = "url"
+		} else if !isWebURL(a.URL) {
+			return fmt.Errorf("link action url %q is not an http or https URL", a.URL)
+		}
+	case models.ActionConfig:
+		if a.File == "" || a.Key == "" {
+			missing = "file and key"
+		}
+	case models.ActionInstall, models.ActionRemove:
+		if a.Mod == "" && a.Plugin == "" {
+			missing = "mod or plugin"
+		}
+	case models.ActionJVMFlag:
+		if a.Flag == "" {
+			missing = "flag"
+		}
+	default:
+		return fmt.Errorf("unknown action type %q", a.Type)
+	}
+	if missing != "" {
+		return fmt.Errorf("%s action needs %s", a.Type, missing)
+	}
+	return nil
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// ruleInput is the log and the extracted data the rules are matched against.
+type ruleInput struct {
+	content string
+	// byte offset of the start of every line
+	lineStarts []int
+	result     *models.AnalysisResult
+	// detected type followed by its parents
+	types []string
+	// literals the prefilter found in content; nil runs every regex
+	filter *prefilter
+	found  []bool
+}
+
+func newRuleInput(content string, result *models.AnalysisResult) *ruleInput {
+	starts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return &ruleInput{content: content, lineStarts: starts, result: result, types: []string{result.Type}}
+}
+
+// lineAt returns the 1-based line number of a byte offset.
+func (in *ruleInput) lineAt(offset int) int {
+	return sort.Search(len(in.lineStarts), func(i int) bool { return in.lineStarts[i] > offset })
+}
+
+// matchLines returns the sorted line numbers on which re matches.
+func (in *ruleInput) matchLines(re *regexp.Regexp) []int {
+	return in.matchLinesIn(re, []lineRange{{1, len(in.lineStarts)}})
+}
+
+// lineRange is a span of lines, both ends included.
+type lineRange struct{ from, to int }
+
+// windows returns the lines within distance of any of the given lines as
+// sorted ranges that don't overlap.
+func (in *ruleInput) windows(lines []int, distance int) []lineRange {
+	sorted := slices.Clone(lines)
+	slices.Sort(sorted)
+
+	var ranges []lineRange
+	for _, line := range sorted {
+		from, to := max(1, line-distance), min(len(in.lineStarts), line+distance)
+		if n := len(ranges); n > 0 && from <= ranges[n-1].to+1 {
+			ranges[n-1].to = max(ranges[n-1].to, to)
+			continue
+		}
+		ranges = append(ranges, lineRange{from, to})
+	}
+	return ranges
+}
+
+// matchLinesIn returns the sorted line numbers on which re matches, looking
+// only at the given ranges. Each range is matched on its own, so ^ and \A
+// match at the start of a range.
+func (in *ruleInput) matchLinesIn(re *regexp.Regexp, ranges []lineRange) []int {
+	var lines []int
+	for _, rg := range ranges {
+		start := in.lineStarts[rg.from-1]
+		end := len(in.content)
+		if rg.to < len(in.lineStarts) {
+			end = in.lineStarts[rg.to]
+		}
+		for _, loc := range re.FindAllStringIndex(in.content[start:end], -1) {
+			if line := in.lineAt(start + loc[0]); len(lines) == 0 || lines[len(lines)-1] != line {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
+}
+
+// match reports whether the rule applies and returns the capturing groups
+// used for placeholder replacement. Groups come from the rule's first
+// pattern, or from its exception regex or mod versions when it has none.
+func (r *compiledRule) match(in *ruleInput) ([]string, bool) {
+	if !r.appliesTo(in.types, in.result.Version) || !r.possible(in) {
+		return nil, false
+	}
+
+	var groups []string
+	var ok bool
+	if r.WithinLines > 0 {
+		groups, ok = r.matchNear(in)
+	} else {
+		groups, ok = r.matchAnywhere(in)
+	}
+	if !ok {
+		return nil, false
+	}
+	return r.matchExtracted(in.result, groups)
+}
+
+// appliesTo checks the rule's type and version filters.
+func (r *compiledRule) appliesTo(types []string, version string) bool {
+	if len(r.Types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return slices.Contains(r.Types, t) }) {
+		return false
+	}
+	if r.Versions != "" && (version == "unknown" || !matchVersionRange(version, r.Versions)) {
+		return false
+	}
+	return true
+}
+
+// matchExtracted checks the conditions on the extracted mods and exceptions
+// once the text conditions have matched with the given groups.
+func (r *compiledRule) matchExtracted(result *models.AnalysisResult, groups []string) ([]string, bool) {
+	if len(r.Mods) > 0 {
+		versions, ok := matchMods(r.Mods, result.Mods)
+		if !ok {
+			return nil, false
+		}
+		if groups == nil {
+			groups = append([]string{""}, versions...)
+		}
+	}
+
+	if r.exception != nil || r.FramePackage != "" {
+		exGroups, ok := r.matchException(result.Exceptions)
+		if !ok {
+			return nil, false
+		}
+		if groups == nil {
+			groups = exGroups
+		}
+	}
+
+	return groups, true
+}
+
+// possible reports whether the literals in the log leave the rule a chance:
+// its pattern and all_of must be able to match, and one of its any_of.
+func (r *compiledRule) possible(in *ruleInput) bool {
+	if r.pattern != nil && !in.filter.mayMatch(r.pattern, in.found) {
+		return false
+	}
+	for _, re := range r.allOf {
+		if !in.filter.mayMatch(re, in.found) {
+			return false
+		}
+	}
+	if len(r.anyOf) == 0 {
+		return true
+	}
+	for _, re := range r.anyOf {
+		if in.filter.mayMatch(re, in.found) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *compiledRule) matchAnywhere(in *ruleInput) ([]string, bool) {
+	var groups []string
+	if r.pattern != nil {
+		if groups = r.pattern.FindStringSubmatch(in.content); groups == nil {
+			return nil, false
+		}
+	}
+	for i, re := range r.allOf {
+		m := re.FindStringSubmatch(in.content)
+		if m == nil {
+			return nil, false
+		}
+		if i == 0 && groups == nil {
+			groups = m
+		}
+	}
+	if len(r.anyOf) > 0 {
+		var found []string
+		for _, re := range r.anyOf {
+			if found = re.FindStringSubmatch(in.content); found != nil {
+				break
+			}
+		}
+		if found == nil {
+			return nil, false
+		}
+		if groups == nil {
+			groups = found
+		}
+	}
+	for _, re := range r.noneOf {
+		if re.MatchString(in.content) {
+			return nil, false
+		}
+	}
+	return groups, true
+}
+
+// matchNear looks for an occurrence of the rule's first pattern that has
+// all other patterns within WithinLines lines of it. The other patterns are
+// only searched for around the anchors, so a rule whose anchor does not
+// occur costs a single pass over the log.
+func (r *compiledRule) matchNear(in *ruleInput) ([]string, bool) {
+	anchors, allOf, anyOf := r.nearAnchors()
+	if len(anchors) == 0 {
+		// Only exception or mod conditions, there is nothing to be near to,
+		// so none_of applies to the whole log
+		for _, re := range r.noneOf {
+			if re.MatchString(in.content) {
+				return nil, false
+			}
+		}
+		return nil, true
+	}
+
+	var locs [][]int
+	for _, anchor := range anchors {
+		locs = append(locs, anchor.FindAllStringSubmatchIndex(in.content, -1)...)
+	}
+	if len(locs) == 0 {
+		return nil, false
+	}
+	lines := make([]int, len(locs))
+	for i, loc := range locs {
+		lines[i] = in.lineAt(loc[0])
+	}
+	windows := in.windows(lines, r.WithinLines)
+
+	allLines := make([][]int, len(allOf))
+	for i, re := range allOf {
+		if allLines[i] = in.matchLinesIn(re, windows); allLines[i] == nil {
+			return nil, false
+		}
+	}
+	var anyLines [][]int
+	for _, re := range anyOf {
+		anyLines = append(anyLines, in.matchLinesIn(re, windows))
+	}
+	var noneLines [][]int
+	for _, re := range r.noneOf {
+		noneLines = append(noneLines, in.matchLinesIn(re, windows))
+	}
+
+	for i, loc := range locs {
+		line := lines[i]
+		if !r.nearAll(line, allLines) || !r.nearNone(line, noneLines) {
+			continue
+		}
+		if len(anyLines) > 0 && !r.nearAny(line, anyLines) {
+			continue
+		}
+		return submatches(in.content, loc), true
+	}
+	return nil, false
+}
+
+// nearAnchors splits the rule's patterns for matchNear into the anchors and
+// the all_of and any_of patterns that have to be near one of them.
+func (r *compiledRule) nearAnchors() (anchors, allOf, anyOf []*regexp.Regexp) {
+	switch {
+	case r.pattern != nil:
+		return []*regexp.Regexp{r.pattern}, r.allOf, r.anyOf
+	case len(r.allOf) > 0:
+		return r.allOf[:1], r.allOf[1:], r.anyOf
+	case len(r.anyOf) > 0:
+		// any_of is satisfied by the anchor itself
+		return r.anyOf, nil, nil
+	}
+	return nil, nil, nil
+}
+
+func (r *compiledRule) nearAll(line int, lines [][]int) bool {
+	for _, l := range lines {
+		if !hasLineWithin(l, line, r.WithinLines) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *compiledRule) nearAny(line int, lines [][]int) bool {
+	for _, l := range lines {
+		if hasLineWithin(l, line, r.WithinLines) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *compiledRule) nearNone(line int, lines [][]int) bool {
+	for _, l := range lines {
+		if hasLineWithin(l, line, r.WithinLines) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasLineWithin reports whether the sorted lines contain one within
+// distance of line.
+func hasLineWithin(lines []int, line, distance int) bool {
+	i := sort.SearchInts(lines, line-distance)
+	return i < len(lines) && lines[i] <= line+distance
+}
+
+func submatches(content string, loc []int) []string {
+	groups := make([]string, len(loc)/2)
+	for i := range groups {
+		if loc[2*i] >= 0 {
+			groups[i] = content[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return groups
+}
+
+func (r *compiledRule) matchException(exceptions []*models.JavaException) ([]string, bool) {
+	var groups []string
+	found := false
+	for _, top := range exceptions {
+		top.Walk(func(ex *models.JavaException) {
+			if found {
+				return
+			}
+			var m []string
+			if r.exception != nil {
+				if m = r.exception.FindStringSubmatch(ex.Class); m == nil {
+					return
+				}
+			}
+			if r.FramePackage != "" && !hasFrameInPackage(ex, r.FramePackage) {
+				return
+			}
+			groups, found = m, true
+		})
+		if found {
+			break
+		}
+	}
+	return groups, found
+}
+
+func hasFrameInPackage(ex *models.JavaException, pkg string) bool {
+	for _, frame := range ex.Frames {
+		if p := frame.Package(); p == pkg || strings.HasPrefix(p, pkg+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// problem builds the problem reported by the rule, in the given locale.
+func (r *compiledRule) problem(groups []string, locale, fallback string) *models.Problem {
+	p := &models.Problem{
+		Severity: r.Severity,
+		Message:  expandPlaceholders(r.Message.Get(locale, fallback), groups),
+	}
+	for _, s := range r.Solutions {
+		sol := models.Solution{
+			Message: expandPlaceholders(s.Message.Get(locale, fallback), groups),
+		}
+		if a := s.Action; a != nil {
+			expand := func(s string) string { return expandPlaceholders(s, groups) }
+			sol.Action = &models.SolutionAction{
+				Type:   a.Type,
+				Label:  expand(a.Label.Get(locale, fallback)),
+				URL:    expand(a.URL),
+				File:   expand(a.File),
+				Key:    expand(a.Key),
+				Value:  expand(a.Value),
+				Mod:    expand(a.Mod),
+				Plugin: expand(a.Plugin),
+				Flag:   expand(a.Flag),
+			}
+		}
+		// a placeholder can put anything in the URL, and the frontend
+		// turns it into a link
+		if sol.Action != nil && sol.Action.URL != "" && !isWebURL(sol.Action.URL) {
+			sol.Action = nil
+		}
+		p.Solutions = append(p.Solutions, sol)
+	}
+	return p
+}
+
+// isWebURL reports whether s is an absolute http or https URL.
+func isWebURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// expandPlaceholders replaces $1, $2... with the capturing groups. Higher
+// numbers go first so $1 does not eat the start of $10.
+func expandPlaceholders(s string, groups []string) string {
+	for i := len(groups) - 1; i >= 1; i-- {
+		s = strings.ReplaceAll(s, "$"+strconv.Itoa(i), groups[i])
+	}
+	return s
+}
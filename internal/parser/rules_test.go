@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"mclogs-go/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProblemLinkURL(t *testing.T) {
+	r := &compiledRule{AnalyzerRule: models.AnalyzerRule{
+		Message: models.LocalizedText{"en": "Mod $1 is broken"},
+		Solutions: []models.RuleSolution{{
+			Message: models.LocalizedText{"en": "Get a fixed version."},
+			Action:  &models.RuleAction{Type: models.ActionLink, URL: "$2"},
+		}},
+	}}
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://modrinth.com/mod/sodium", true},
+		{"http://example.com/", true},
+		{"javascript:alert(1)", false},
+		{"//evil.example/", false},
+		{"data:text/html,hi", false},
+	}
+	for _, tt := range tests {
+		p := r.problem([]string{"", "sodium", tt.url}, "en", "en")
+		if got := p.Solutions[0].Action != nil; got != tt.want {
+			t.Errorf("%q: action kept = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+
+	if err := checkAction(&models.RuleAction{Type: models.ActionLink, URL: "javascript:void(0)"}); err == nil {
+		t.Error("checkAction accepted a javascript: link")
+	}
+}
+
+func TestCompileRuleVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		rule models.AnalyzerRule
+	}{
+		{"versions", models.AnalyzerRule{Pattern: "x", Versions: "=>1.20"}},
+		{"mod version", models.AnalyzerRule{Pattern: "x", Mods: []models.ModCondition{{ID: "sodium", Version: "=<0.5"}}}},
+	}
+	for _, tt := range tests {
+		if _, err := compileRule(tt.rule); err == nil {
+			t.Errorf("%s: compileRule accepted an unknown operator", tt.name)
+		}
+	}
+}
+
+// TestNearNoneOfWithoutAnchors checks that a within_lines rule with only
+// extracted conditions still honors none_of, on the whole log.
+func TestNearNoneOfWithoutAnchors(t *testing.T) {
+	patterns := filepath.Join(t.TempDir(), "patterns.yaml")
+	err := os.WriteFile(patterns, []byte(`
+analyzers:
+  - name: "Heap"
+    exception: "^java\\.lang\\.OutOfMemoryError$"
+    within_lines: 2
+    none_of: ["-Xmx\\d+G"]
+    message: "Out of memory"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewEngine(patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const trace = "java.lang.OutOfMemoryError: Java heap space\n\tat net.example.Foo.bar(Foo.java:1)\n"
+	tests := []struct {
+		name string
+		log  string
+		want int
+	}{
+		{"no flag", trace, 1},
+		{"flag far away", "JVM Flags: -Xmx4G\n" + strings.Repeat("filler\n", 10) + trace, 0},
+	}
+	for _, tt := range tests {
+		if got := len(e.Analyze(tt.log, "").Problems); got != tt.want {
+			t.Errorf("%s: Analyze found %d problems, want %d", tt.name, got, tt.want)
+		}
+		result, err := e.AnalyzeReader(strings.NewReader(tt.log), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(result.Problems); got != tt.want {
+			t.Errorf("%s: AnalyzeReader found %d problems, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package parser
+
+import (
+	"mclogs-go/internal/models"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxExceptions bounds how many top-level exceptions are kept per log. Logs
+// that spam the same trace thousands of times would otherwise blow up the
+// insights response.
+const maxExceptions = 100
+
+// maxFrames bounds the frames kept per exception and maxNested the causes
+// and suppressed exceptions kept per top-level one, so a single trace
+// cannot grow without limit either.
+const (
+	maxFrames = 256
+	maxNested = 64
+)
+
+var (
+	// An exception header, optionally behind a log prefix such as
+	// "[12:00:00] [Server thread/ERROR]: " or "Exception in thread "main" ".
+	exceptionHeaderRe = regexp.MustCompile(`^(?:.*?\]:?\s+)?(?:Exception in thread "[^"]*"\s+)?((?:[a-zA-Z_$][\w$]*\.)+[a-zA-Z_$][\w$]*)(?::\s?(.*))?$`)
+	causedByRe        = regexp.MustCompile(`^Caused by:\s+((?:[a-zA-Z_$][\w$]*\.)+[a-zA-Z_$][\w$]*)(?::\s?(.*))?$`)
+	suppressedRe      = regexp.MustCompile(`^Suppressed:\s+((?:[a-zA-Z_$][\w$]*\.)+[a-zA-Z_$][\w$]*)(?::\s?(.*))?$`)
+	// "at [module/]some.pkg.Class.method(File.java:123) ~[jar:?]"
+	stackFrameRe = regexp.MustCompile(`^at\s+(?:[^\s(/]+/)*([^\s(/]+)\.([^\s(.]+)\(([^)]*)\)`)
+	moreFramesRe = regexp.MustCompile(`^\.\.\. (\d+) more`)
+)
+
+type traceContext struct {
+	ex *models.JavaException
+	// indent at which this exception's own "Caused by:" lines appear
+	indent int
+}
+
+// stackTraceParser extracts Java exceptions from a log one line at a time.
+type stackTraceParser struct {
+	exceptions []*models.JavaException
+	stack      []traceContext
+	// causes and suppressed exceptions of the current trace
+	nested int
+
+	// header line seen just before, waiting for its first frame
+	pending *models.JavaException
+}
+
+func (p *stackTraceParser) feed(lineNo int, line string) {
+	trimmed := strings.TrimSpace(line)
+	indent := indentWidth(line)
+
+	if len(p.stack) > 0 {
+		current := p.stack[len(p.stack)-1].ex
+
+		if m := stackFrameRe.FindStringSubmatch(trimmed); m != nil {
+			if len(current.Frames) < maxFrames {
+				current.Frames = append(current.Frames, newStackFrame(m))
+			}
+			return
+		}
+		if m := moreFramesRe.FindStringSubmatch(trimmed); m != nil {
+			current.More, _ = strconv.Atoi(m[1])
+			return
+		}
+		if m := causedByRe.FindStringSubmatch(trimmed); m != nil && p.nested < maxNested {
+			p.nested++
+			for len(p.stack) > 1 && p.stack[len(p.stack)-1].indent > indent {
+				p.stack = p.stack[:len(p.stack)-1]
+			}
+			top := &p.stack[len(p.stack)-1]
+			cause := &models.JavaException{Class: m[1], Message: m[2], Line: lineNo}
+			top.ex.CausedBy = cause
+			top.ex = cause
+			return
+		}
+		if m := suppressedRe.FindStringSubmatch(trimmed); m != nil && p.nested < maxNested {
+			p.nested++
+			for len(p.stack) > 1 && p.stack[len(p.stack)-1].indent >= indent {
+				p.stack = p.stack[:len(p.stack)-1]
+			}
+			top := p.stack[len(p.stack)-1]
+			suppressed := &models.JavaException{Class: m[1], Message: m[2], Line: lineNo}
+			top.ex.Suppressed = append(top.ex.Suppressed, suppressed)
+			p.stack = append(p.stack, traceContext{ex: suppressed, indent: indent})
+			return
+		}
+
+		// Anything else ends the trace
+		p.stack = nil
+	}
+
+	if p.pending != nil {
+		if m := stackFrameRe.FindStringSubmatch(trimmed); m != nil {
+			p.pending.Frames = append(p.pending.Frames, newStackFrame(m))
+			if len(p.exceptions) < maxExceptions {
+				p.exceptions = append(p.exceptions, p.pending)
+				p.stack = []traceContext{{ex: p.pending, indent: max(indent-indentWidth("\t"), 0)}}
+				p.nested = 0
+			}
+			p.pending = nil
+			return
+		}
+		p.pending = nil
+	}
+
+	if m := exceptionHeaderRe.FindStringSubmatch(line); m != nil {
+		p.pending = &models.JavaException{Class: m[1], Message: m[2], Line: lineNo}
+	}
+}
+
+func (p *stackTraceParser) finish(result *models.AnalysisResult) {
+	result.Exceptions = p.exceptions
+	if root := deepestRootCause(p.exceptions); root != nil {
+		result.RootCause = root
+		result.Information = append(result.Information, models.Info{Label: label(result.Locale, "Root Cause"), Value: root.String()})
+	}
+}
+
+func newStackFrame(m []string) models.StackFrame {
+	frame := models.StackFrame{Class: m[1], Method: m[2], File: m[3]}
+	if i := strings.LastIndex(m[3], ":"); i >= 0 {
+		if n, err := strconv.Atoi(m[3][i+1:]); err == nil {
+			frame.File = m[3][:i]
+			frame.Line = n
+		}
+	}
+	return frame
+}
+
+// indentWidth measures leading whitespace, counting a tab as four spaces so
+// traces that had their tabs expanded still nest correctly.
+func indentWidth(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// deepestRootCause picks the root cause of the exception with the longest
+// "Caused by" chain, preferring the earliest one on ties.
+func deepestRootCause(exceptions []*models.JavaException) *models.JavaException {
+	var best *models.JavaException
+	bestDepth := -1
+	for _, ex := range exceptions {
+		if d := ex.Depth(); d > bestDepth {
+			best, bestDepth = ex, d
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return best.RootCause()
+}
@@ -0,0 +1,595 @@
+package parser
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"mclogs-go/internal/models"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// multilineWindow is how many lines a pattern that spells out a line break
+// gets to see at once when streaming.
+const multilineWindow = 20
+
+// maxLineLength bounds the bytes of a line that are analyzed when
+// streaming; the rest of a longer line is skipped.
+const maxLineLength = 64 * 1024
+
+// lineRegex is a regex prepared for matching one line at a time.
+type lineRegex struct {
+	re *regexp.Regexp
+	// prefilter literals, one of which a line must contain to match
+	need []int
+	// the pattern contains "\n" or (?s), so it is matched against the
+	// last multilineWindow lines instead of the current one
+	multiline bool
+	// the pattern starts with ^ outside of (?m), so only the first line
+	// can match
+	firstLine bool
+}
+
+func newLineRegex(re *regexp.Regexp, p *prefilter) *lineRegex {
+	lr := &lineRegex{re: re, need: p.needs[re]}
+	if parsed, err := syntax.Parse(re.String(), syntax.Perl); err == nil {
+		lr.multiline = spansLines(parsed)
+		lr.firstLine = anchoredAtStart(parsed)
+	}
+	if lr.multiline {
+		lr.need = nil
+	}
+	return lr
+}
+
+// spansLines reports whether a regex can only match across a line break.
+func spansLines(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpAnyChar:
+		return true
+	case syntax.OpLiteral:
+		return strings.ContainsRune(string(re.Rune), '\n')
+	}
+	for _, sub := range re.Sub {
+		if spansLines(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// anchoredAtStart reports whether a regex only matches at the start of the
+// text.
+func anchoredAtStart(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpBeginText:
+		return true
+	case syntax.OpConcat, syntax.OpCapture:
+		return len(re.Sub) > 0 && anchoredAtStart(re.Sub[0])
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if !anchoredAtStart(sub) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// skip reports whether the current line cannot contain a match.
+func (lr *lineRegex) skip(w *lineWindow) bool {
+	if lr.firstLine && w.lineNo != 1 {
+		return true
+	}
+	return lr.need != nil && !anyFound(lr.need, w.found)
+}
+
+func (lr *lineRegex) text(w *lineWindow) string {
+	if lr.multiline {
+		return w.joined()
+	}
+	return w.line
+}
+
+func (lr *lineRegex) find(w *lineWindow) []string {
+	if lr.skip(w) {
+		return nil
+	}
+	return lr.re.FindStringSubmatch(lr.text(w))
+}
+
+func (lr *lineRegex) matches(w *lineWindow) bool {
+	if lr.skip(w) {
+		return false
+	}
+	return lr.re.MatchString(lr.text(w))
+}
+
+// lineWindow is the current line plus, if any pattern needs them, the lines
+// before it.
+type lineWindow struct {
+	lineNo int
+	line   string
+	// prefilter literals in line
+	found []bool
+	// ring of the last multilineWindow lines, nil if no pattern spans lines
+	recent []string
+	text   string
+	cached bool
+}
+
+func (w *lineWindow) push(lineNo int, line string, m *literalMatcher) {
+	w.lineNo, w.line, w.cached = lineNo, line, false
+	clear(w.found)
+	m.scan(line, w.found)
+	if w.recent != nil {
+		w.recent[lineNo%len(w.recent)] = line
+	}
+}
+
+func (w *lineWindow) joined() string {
+	if !w.cached {
+		var sb strings.Builder
+		for i := max(1, w.lineNo-len(w.recent)+1); i <= w.lineNo; i++ {
+			if sb.Len() > 0 {
+				sb.WriteByte('\n')
+			}
+			sb.WriteString(w.recent[i%len(w.recent)])
+		}
+		w.text, w.cached = sb.String(), true
+	}
+	return w.text
+}
+
+// prepareStream prepares every regex for the streaming path. It runs once
+// in NewEngine, after the prefilter is built.
+func (e *Engine) prepareStream() {
+	e.lineRegexes = make(map[*regexp.Regexp]*lineRegex)
+	for _, re := range e.regexes() {
+		if e.lineRegexes[re] == nil {
+			lr := newLineRegex(re, e.prefilter)
+			e.lineRegexes[re] = lr
+			e.multiline = e.multiline || lr.multiline
+		}
+	}
+}
+
+func (e *Engine) lineRegexesOf(res []*regexp.Regexp) []*lineRegex {
+	lrs := make([]*lineRegex, len(res))
+	for i, re := range res {
+		lrs[i] = e.lineRegexes[re]
+	}
+	return lrs
+}
+
+// AnalyzeReader analyzes a log in a single pass over its lines, without
+// holding the whole log as one string. Memory does not grow with the log:
+// only the first maxLineLength bytes of a line are read, and the extractors
+// keep a bounded number of exceptions, frames, mods and plugins. It gives
+// the same result as Analyze with four exceptions: type-specific
+// extractors only see the log from the line their detector first matched
+// on, patterns spanning lines only see multilineWindow lines at a time, $
+// outside (?m) matches at the end of every line rather than only at the end
+// of the log (Lint warns about it), and longer lines are cut short.
+func (e *Engine) AnalyzeReader(r io.Reader, locale string) (*models.AnalysisResult, error) {
+	s := e.newStreamState()
+
+	br := bufio.NewReaderSize(r, maxLineLength)
+	lineNo := 0
+	for {
+		line, err := readLine(br)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if line != "" || err == nil {
+			lineNo++
+			line = strings.TrimSuffix(line, "\n")
+			s.feed(lineNo, strings.TrimSuffix(line, "\r"))
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	return s.finish(locale), nil
+}
+
+// readLine reads up to and including the next line break, like
+// ReadString('\n'), but keeps only the first buffer full of a longer line
+// and skips the rest of it.
+func readLine(br *bufio.Reader) (string, error) {
+	b, err := br.ReadSlice('\n')
+	if !errors.Is(err, bufio.ErrBufferFull) {
+		return string(b), err
+	}
+	// don't leave half a character at the cut
+	line := strings.ToValidUTF8(string(b), "")
+	for errors.Is(err, bufio.ErrBufferFull) {
+		_, err = br.ReadSlice('\n')
+	}
+	return line, err
+}
+
+type streamState struct {
+	e      *Engine
+	window lineWindow
+
+	// per detector, by index in e.detectors
+	signalsSeen [][]bool
+	scores      []float64
+	versions    [][]string
+
+	extractors []extractor
+	// type-specific extractors, started once their detector scores
+	typed map[string]extractor
+
+	anywhere []*anywhereState
+	near     []*nearState
+}
+
+func (e *Engine) newStreamState() *streamState {
+	s := &streamState{
+		e:           e,
+		signalsSeen: make([][]bool, len(e.detectors)),
+		scores:      make([]float64, len(e.detectors)),
+		versions:    make([][]string, len(e.detectors)),
+		extractors:  []extractor{&stackTraceParser{}, &modListParser{}, &pluginListParser{}},
+		typed:       make(map[string]extractor),
+		anywhere:    make([]*anywhereState, len(e.rules)),
+		near:        make([]*nearState, len(e.rules)),
+	}
+	s.window.found = make([]bool, len(e.prefilter.matcher.literals))
+	if e.multiline {
+		s.window.recent = make([]string, multilineWindow)
+	}
+	for i, det := range e.detectors {
+		s.signalsSeen[i] = make([]bool, len(det.signals))
+	}
+	for i, r := range e.rules {
+		if r.WithinLines > 0 {
+			s.near[i] = newNearState(e, r)
+		} else {
+			s.anywhere[i] = newAnywhereState(e, r)
+		}
+	}
+	return s
+}
+
+func (s *streamState) feed(lineNo int, line string) {
+	w := &s.window
+	w.push(lineNo, line, s.e.prefilter.matcher)
+
+	for i, det := range s.e.detectors {
+		for j, sig := range det.signals {
+			if !s.signalsSeen[i][j] && s.e.lineRegexes[sig.re].matches(w) {
+				s.signalsSeen[i][j] = true
+				s.scores[i] += sig.weight
+			}
+		}
+		if det.version != nil && s.versions[i] == nil {
+			s.versions[i] = s.e.lineRegexes[det.version].find(w)
+		}
+		if s.scores[i] > 0 && s.typed[det.Type] == nil {
+			if newExtractor, ok := typeExtractors[det.Type]; ok {
+				s.typed[det.Type] = newExtractor()
+			}
+		}
+	}
+
+	for _, ex := range s.extractors {
+		ex.feed(lineNo, line)
+	}
+	for _, ex := range s.typed {
+		ex.feed(lineNo, line)
+	}
+
+	for i := range s.e.rules {
+		if s.anywhere[i] != nil {
+			s.anywhere[i].feed(w)
+		} else {
+			s.near[i].feed(w)
+		}
+	}
+}
+
+func (s *streamState) finish(locale string) *models.AnalysisResult {
+	e := s.e
+	result := &models.AnalysisResult{
+		Name:    "Unknown Log",
+		Type:    "unknown",
+		Version: "unknown",
+		Locale:  e.resolveLocale(locale),
+	}
+	e.applyDetection(result, s.scores, func(det *compiledDetector) []string {
+		for i, d := range e.detectors {
+			if d == det {
+				return s.versions[i]
+			}
+		}
+		return nil
+	})
+
+	for _, ex := range s.extractors {
+		ex.finish(result)
+	}
+	if ex := s.typed[result.Type]; ex != nil {
+		ex.finish(result)
+	}
+
+	types := e.lineage(result.Type)
+	for i, r := range e.rules {
+		if !r.appliesTo(types, result.Version) {
+			continue
+		}
+		var groups []string
+		var ok bool
+		if s.anywhere[i] != nil {
+			groups, ok = s.anywhere[i].result()
+		} else {
+			groups, ok = s.near[i].finish()
+		}
+		if !ok {
+			continue
+		}
+		if groups, ok = r.matchExtracted(result, groups); ok {
+			result.Problems = append(result.Problems, *r.problem(groups, result.Locale, e.fallback))
+		}
+	}
+	return result
+}
+
+// anywhereState tracks a rule without within_lines, whose patterns may
+// match on any line.
+type anywhereState struct {
+	r                    *compiledRule
+	pattern              *lineRegex
+	allOf, anyOf, noneOf []*lineRegex
+
+	patternGroups []string
+	allOfFound    []bool
+	allOfGroups   []string
+	// first match of each any_of pattern
+	anyOfGroups [][]string
+	noneSeen    bool
+}
+
+func newAnywhereState(e *Engine, r *compiledRule) *anywhereState {
+	st := &anywhereState{
+		r:           r,
+		allOf:       e.lineRegexesOf(r.allOf),
+		anyOf:       e.lineRegexesOf(r.anyOf),
+		noneOf:      e.lineRegexesOf(r.noneOf),
+		allOfFound:  make([]bool, len(r.allOf)),
+		anyOfGroups: make([][]string, len(r.anyOf)),
+	}
+	if r.pattern != nil {
+		st.pattern = e.lineRegexes[r.pattern]
+	}
+	return st
+}
+
+func (st *anywhereState) feed(w *lineWindow) {
+	if st.noneSeen {
+		return
+	}
+	for _, lr := range st.noneOf {
+		if lr.matches(w) {
+			st.noneSeen = true
+			return
+		}
+	}
+	if st.pattern != nil && st.patternGroups == nil {
+		st.patternGroups = st.pattern.find(w)
+	}
+	for i, lr := range st.allOf {
+		if st.allOfFound[i] {
+			continue
+		}
+		if i == 0 {
+			st.allOfGroups = lr.find(w)
+			st.allOfFound[i] = st.allOfGroups != nil
+		} else {
+			st.allOfFound[i] = lr.matches(w)
+		}
+	}
+	for i, lr := range st.anyOf {
+		if st.anyOfGroups[i] == nil {
+			st.anyOfGroups[i] = lr.find(w)
+		}
+	}
+}
+
+// result mirrors matchAnywhere.
+func (st *anywhereState) result() ([]string, bool) {
+	if st.noneSeen {
+		return nil, false
+	}
+	var groups []string
+	if st.pattern != nil {
+		if groups = st.patternGroups; groups == nil {
+			return nil, false
+		}
+	}
+	for _, found := range st.allOfFound {
+		if !found {
+			return nil, false
+		}
+	}
+	if groups == nil && len(st.allOf) > 0 {
+		groups = st.allOfGroups
+	}
+	if len(st.anyOf) > 0 {
+		var found []string
+		for _, g := range st.anyOfGroups {
+			if g != nil {
+				found = g
+				break
+			}
+		}
+		if found == nil {
+			return nil, false
+		}
+		if groups == nil {
+			groups = found
+		}
+	}
+	return groups, true
+}
+
+// nearState tracks a rule with within_lines. It keeps which of the rule's
+// patterns matched on the last 2*within_lines+1 lines and checks the line
+// in the middle once every line it can be near has been read.
+type nearState struct {
+	r                     *compiledRule
+	anchors, allOf, anyOf []*lineRegex
+	noneOf                []*lineRegex
+	slots                 []nearSlot
+	lastLine              int
+	// first qualifying match of each anchor
+	best [][]string
+	// without anchors, whether a none_of pattern matched anywhere
+	noneSeen bool
+}
+
+type nearSlot struct {
+	lineNo  int
+	anchors [][]string
+	allOf   []bool
+	anyOf   []bool
+	noneOf  []bool
+}
+
+func newNearState(e *Engine, r *compiledRule) *nearState {
+	anchors, allOf, anyOf := r.nearAnchors()
+	st := &nearState{
+		r:       r,
+		anchors: e.lineRegexesOf(anchors),
+		allOf:   e.lineRegexesOf(allOf),
+		anyOf:   e.lineRegexesOf(anyOf),
+		noneOf:  e.lineRegexesOf(r.noneOf),
+		slots:   make([]nearSlot, 2*r.WithinLines+1),
+		best:    make([][]string, len(anchors)),
+	}
+	for i := range st.slots {
+		st.slots[i] = nearSlot{
+			anchors: make([][]string, len(anchors)),
+			allOf:   make([]bool, len(allOf)),
+			anyOf:   make([]bool, len(anyOf)),
+			noneOf:  make([]bool, len(r.noneOf)),
+		}
+	}
+	return st
+}
+
+func (st *nearState) slot(lineNo int) *nearSlot {
+	return &st.slots[lineNo%len(st.slots)]
+}
+
+// done reports whether the result can no longer change: the first anchor
+// has qualified.
+func (st *nearState) done() bool {
+	return st.best[0] != nil
+}
+
+func (st *nearState) feed(w *lineWindow) {
+	if len(st.anchors) == 0 {
+		// like matchNear, none_of then applies to the whole log
+		for _, lr := range st.noneOf {
+			st.noneSeen = st.noneSeen || lr.matches(w)
+		}
+		return
+	}
+	if st.done() {
+		return
+	}
+	sl := st.slot(w.lineNo)
+	sl.lineNo = w.lineNo
+	for i, lr := range st.anchors {
+		sl.anchors[i] = lr.find(w)
+	}
+	for i, lr := range st.allOf {
+		sl.allOf[i] = lr.matches(w)
+	}
+	for i, lr := range st.anyOf {
+		sl.anyOf[i] = lr.matches(w)
+	}
+	for i, lr := range st.noneOf {
+		sl.noneOf[i] = lr.matches(w)
+	}
+	st.lastLine = w.lineNo
+
+	if center := w.lineNo - st.r.WithinLines; center >= 1 {
+		st.check(center)
+	}
+}
+
+// finish checks the lines that were still waiting for the lines after them.
+func (st *nearState) finish() ([]string, bool) {
+	if len(st.anchors) == 0 {
+		return nil, !st.noneSeen
+	}
+	for center := max(1, st.lastLine-st.r.WithinLines+1); center <= st.lastLine && !st.done(); center++ {
+		st.check(center)
+	}
+	for _, g := range st.best {
+		if g != nil {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+func (st *nearState) check(center int) {
+	c := st.slot(center)
+	if c.lineNo != center {
+		return
+	}
+	matched := false
+	for _, g := range c.anchors {
+		matched = matched || g != nil
+	}
+	if !matched {
+		return
+	}
+
+	n := st.r.WithinLines
+	from, to := max(1, center-n), min(st.lastLine, center+n)
+	window := func(flags func(*nearSlot) []bool, i int) bool {
+		for l := from; l <= to; l++ {
+			if sl := st.slot(l); sl.lineNo == l && flags(sl)[i] {
+				return true
+			}
+		}
+		return false
+	}
+	for i := range st.allOf {
+		if !window(func(sl *nearSlot) []bool { return sl.allOf }, i) {
+			return
+		}
+	}
+	for i := range st.noneOf {
+		if window(func(sl *nearSlot) []bool { return sl.noneOf }, i) {
+			return
+		}
+	}
+	if len(st.anyOf) > 0 {
+		found := false
+		for i := range st.anyOf {
+			if window(func(sl *nearSlot) []bool { return sl.anyOf }, i) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return
+		}
+	}
+
+	for i, g := range c.anchors {
+		if g != nil && st.best[i] == nil {
+			st.best[i] = g
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestAnalyzeReader checks that streaming gives the same result as Analyze
+// on the corpus and on every detector and rule example.
+func TestAnalyzeReader(t *testing.T) {
+	e := loadEngine(t)
+
+	logs := map[string]string{}
+	paths, err := filepath.Glob("testdata/corpus/*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logs[filepath.Base(path)] = string(content)
+	}
+	for _, det := range e.detectors {
+		for i, example := range det.Examples {
+			logs[det.Name+"/example"+strconv.Itoa(i)] = example
+		}
+	}
+	for _, rule := range e.rules {
+		for i, example := range rule.Examples {
+			logs[rule.Name+"/example"+strconv.Itoa(i)] = example.Log
+		}
+		for i, example := range rule.CounterExamples {
+			logs[rule.Name+"/counter"+strconv.Itoa(i)] = example
+		}
+	}
+
+	for name, content := range logs {
+		want := e.Analyze(content, "")
+		got, err := e.AnalyzeReader(strings.NewReader(content), "")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s:\nstream  %+v\nanalyze %+v", name, got, want)
+		}
+	}
+}
+
+// largeLog repeats the corpus until it is at least size bytes.
+func largeLog(b *testing.B, size int) string {
+	b.Helper()
+	paths, err := filepath.Glob("testdata/corpus/*.log")
+	if err != nil {
+		b.Fatal(err)
+	}
+	var sb strings.Builder
+	for sb.Len() < size {
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			sb.Write(content)
+		}
+	}
+	return sb.String()
+}
+
+func loadBenchEngine(b *testing.B) *Engine {
+	b.Helper()
+	e, err := NewEngine(patternsFile)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return e
+}
+
+func BenchmarkAnalyze(b *testing.B) {
+	e := loadBenchEngine(b)
+	content := largeLog(b, 8<<20)
+
+	b.SetBytes(int64(len(content)))
+	b.ReportAllocs()
+	for b.Loop() {
+		e.Analyze(content, "")
+	}
+}
+
+func BenchmarkAnalyzeReader(b *testing.B) {
+	e := loadBenchEngine(b)
+	content := largeLog(b, 8<<20)
+
+	b.SetBytes(int64(len(content)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := e.AnalyzeReader(strings.NewReader(content), ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestParseReader checks the Parser entry points agree on the corpus.
+func TestParseReader(t *testing.T) {
+	p, err := NewParser(patternsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, err := filepath.Glob("testdata/corpus/*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, locale := range p.Locales() {
+			want := p.Parse(string(content), locale)
+			got, err := p.ParseReader(strings.NewReader(string(content)), locale)
+			if err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s in %s:\nParseReader %+v\nParse       %+v", path, locale, got, want)
+			}
+		}
+	}
+}
+
+// TestAnalyzeReaderBounds checks that long lines and long lists are cut
+// short instead of kept whole.
+func TestAnalyzeReaderBounds(t *testing.T) {
+	e := loadEngine(t)
+
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat("é", maxLineLength) + "\n")
+	sb.WriteString("java.lang.IllegalStateException: boom\n")
+	for i := range 2 * maxFrames {
+		sb.WriteString("\tat net.example.Foo.bar" + strconv.Itoa(i) + "(Foo.java:1)\n")
+	}
+	sb.WriteString("[main/INFO]: Loading 2000 mods:\n")
+	for i := range 2 * maxMods {
+		sb.WriteString("\t- mod" + strconv.Itoa(i) + " 1.0\n")
+	}
+
+	result, err := e.AnalyzeReader(strings.NewReader(sb.String()), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Exceptions) != 1 {
+		t.Fatalf("got %d exceptions, want 1", len(result.Exceptions))
+	}
+	if ex := result.Exceptions[0]; ex.Line != 2 || len(ex.Frames) != maxFrames {
+		t.Errorf("exception on line %d with %d frames, want line 2 with %d", ex.Line, len(ex.Frames), maxFrames)
+	}
+	if len(result.Mods) != maxMods {
+		t.Errorf("got %d mods, want %d", len(result.Mods), maxMods)
+	}
+
+	line, err := readLine(bufio.NewReaderSize(strings.NewReader(strings.Repeat("é", maxLineLength)+"\nnext\n"), maxLineLength))
+	if err != nil || len(line) > maxLineLength || !utf8.ValidString(line) {
+		t.Errorf("readLine = %d bytes, valid %v, %v; want at most %d valid bytes", len(line), utf8.ValidString(line), err, maxLineLength)
+	}
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"fmt"
+	"mclogs-go/internal/models"
+)
+
+// The problems and information the extractors write themselves are
+// translated here, in the same locales as the patterns file. Texts with
+// arguments are fmt formats.
+var (
+	crashedText          = models.LocalizedText{"en": "The game crashed: %s", "zh-CN": "游戏崩溃：%s"}
+	crashedWithCauseText = models.LocalizedText{"en": "The game crashed: %s (%s)", "zh-CN": "游戏崩溃：%s（%s）"}
+	crashOriginText      = models.LocalizedText{
+		"en":    "The crash originated in %s; update or remove the mod that provides it.",
+		"zh-CN": "崩溃源自 %s，请更新或移除提供它的模组。",
+	}
+	crashWalkthroughText = models.LocalizedText{
+		"en":    "Check the stack trace and the \"A detailed walkthrough\" section for the mod involved.",
+		"zh-CN": "查看堆栈跟踪和“A detailed walkthrough”部分，找出相关的模组。",
+	}
+
+	nativeLibraryText = models.LocalizedText{"en": "%s (%s).", "zh-CN": "%s（%s）。"}
+	nativeOOMText     = models.LocalizedText{
+		"en":    "The JVM ran out of native memory (swap or commit limit reached).",
+		"zh-CN": "JVM 的本机内存不足（已达到交换空间或提交上限）。",
+	}
+	nativeOOMSolutions = []models.LocalizedText{
+		{"en": "Lower -Xmx so the heap leaves room for native memory.", "zh-CN": "调低 -Xmx，为本机内存留出空间。"},
+		{"en": "Increase the page file or swap size, or close other programs.", "zh-CN": "增大页面文件或交换空间，或关闭其他程序。"},
+		{"en": "Make sure you are running a 64-bit Java.", "zh-CN": "确保使用的是 64 位 Java。"},
+	}
+	cpuThreadsText = models.LocalizedText{"en": "%s threads", "zh-CN": "%s 线程"}
+	heapUsageText  = models.LocalizedText{"en": "%s used of %s (%s)", "zh-CN": "已用 %s，共 %s（%s）"}
+	memoryFreeText = models.LocalizedText{"en": "%s (%s free)", "zh-CN": "%s（可用 %s）"}
+
+	pluginFailedText          = models.LocalizedText{"en": "Plugin %s failed to enable.", "zh-CN": "插件 %s 启用失败。"}
+	pluginFailedWithErrorText = models.LocalizedText{"en": "Plugin %s failed to enable: %s", "zh-CN": "插件 %s 启用失败：%s"}
+	pluginSupportText         = models.LocalizedText{
+		"en":    "Check if %s %s supports your server version.",
+		"zh-CN": "检查 %s %s 是否支持你的服务端版本。",
+	}
+)
+
+// labels translates the information labels, keyed by their English text.
+var labels = map[string]models.LocalizedText{
+	"Minecraft Version": {"zh-CN": "Minecraft 版本"},
+	"Operating System":  {"zh-CN": "操作系统"},
+	"Java Version":      {"zh-CN": "Java 版本"},
+	"Java VM":           {"zh-CN": "Java 虚拟机"},
+	"Memory":            {"zh-CN": "内存"},
+	"JVM Flags":         {"zh-CN": "JVM 标志"},
+	"Launched Version":  {"zh-CN": "启动版本"},
+	"Loaded Mods":       {"zh-CN": "已加载模组"},
+	"Error":             {"zh-CN": "错误"},
+	"Problematic Frame": {"zh-CN": "出错的栈帧"},
+	"JRE Version":       {"zh-CN": "JRE 版本"},
+	"Heap Size":         {"zh-CN": "堆大小"},
+	"Heap Usage":        {"zh-CN": "堆使用情况"},
+	"JVM Arguments":     {"zh-CN": "JVM 参数"},
+	"Host":              {"zh-CN": "主机"},
+	"Physical Memory":   {"zh-CN": "物理内存"},
+	"Root Cause":        {"zh-CN": "根本原因"},
+	"Launcher Version":  {"zh-CN": "启动器版本"},
+	"Game Version":      {"zh-CN": "游戏版本"},
+	"Java Path":         {"zh-CN": "Java 路径"},
+	"Java Architecture": {"zh-CN": "Java 架构"},
+	"Exit Code":         {"zh-CN": "退出代码"},
+}
+
+// localize returns text in locale, formatted with args if there are any.
+func localize(locale string, text models.LocalizedText, args ...any) string {
+	s := text.Get(locale, defaultLocale)
+	if len(args) == 0 {
+		return s
+	}
+	return fmt.Sprintf(s, args...)
+}
+
+// label returns the information label name in locale. Labels without a
+// translation stay in English.
+func label(locale, name string) string {
+	t := labels[name]
+	if l := models.MatchLocale([]string{locale}, t.Locales()); l != "" {
+		return t[l]
+	}
+	return name
+}
@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"os"
+	"testing"
+)
+
+// TestLocalizedExtractors checks that what the extractors write themselves
+// is translated like the rule problems are.
+func TestLocalizedExtractors(t *testing.T) {
+	e := loadEngine(t)
+
+	for _, name := range []string{"crash-report", "hs-err-jvm", "hs-err-nvidia", "paper-plugins"} {
+		t.Run(name, func(t *testing.T) {
+			content, err := os.ReadFile("testdata/corpus/" + name + ".log")
+			if err != nil {
+				t.Fatal(err)
+			}
+			en := e.Analyze(string(content), "en")
+			zh := e.Analyze(string(content), "zh-CN")
+
+			if len(zh.Problems) != len(en.Problems) || len(zh.Information) != len(en.Information) {
+				t.Fatalf("zh-CN has %d problems and %d information, en %d and %d",
+					len(zh.Problems), len(zh.Information), len(en.Problems), len(en.Information))
+			}
+			for i, p := range zh.Problems {
+				if p.Message == en.Problems[i].Message {
+					t.Errorf("problem %q is not localized", p.Message)
+				}
+				for j, s := range p.Solutions {
+					if s.Message == en.Problems[i].Solutions[j].Message {
+						t.Errorf("solution %q is not localized", s.Message)
+					}
+				}
+			}
+			for i, info := range zh.Information {
+				if _, ok := labels[info.Label]; ok && info.Label == en.Information[i].Label {
+					t.Errorf("label %q is not localized", info.Label)
+				}
+			}
+		})
+	}
+}
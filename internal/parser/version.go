@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// compareVersions compares two loosely formatted versions such as
+// "0.86.1+1.20.1" or "1.20.1-47.1.0". Build metadata after "+" is ignored,
+// numeric parts compare as numbers and everything else as text.
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y string
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if c := comparePart(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []string {
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func comparePart(x, y string) int {
+	nx, errX := strconv.Atoi(x)
+	ny, errY := strconv.Atoi(y)
+	switch {
+	case x == y:
+		return 0
+	case errX == nil && errY == nil:
+		return compareInts(nx, ny)
+	case x == "":
+		// "1.0" < "1.0.1", but "1.0" > "1.0-beta"
+		if errY == nil {
+			return -1
+		}
+		return 1
+	case y == "":
+		if errX == nil {
+			return 1
+		}
+		return -1
+	case errX == nil:
+		return 1
+	case errY == nil:
+		return -1
+	}
+	return strings.Compare(x, y)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// matchVersionRange checks a version against a space or comma separated
+// list of comparisons (">=1.18 <1.20.5"). A bare version means equality.
+func matchVersionRange(version, constraint string) bool {
+	fields := strings.FieldsFunc(constraint, func(r rune) bool { return r == ' ' || r == ',' })
+	for _, field := range fields {
+		i := strings.IndexFunc(field, func(r rune) bool { return !strings.ContainsRune("<>=!", r) })
+		if i < 0 {
+			return false
+		}
+		op, c := field[:i], compareVersions(version, field[i:])
+		if op == "" {
+			op = "="
+		}
+
+		var ok bool
+		switch op {
+		case "=", "==":
+			ok = c == 0
+		case "!=":
+			ok = c != 0
+		case "<":
+			ok = c < 0
+		case "<=":
+			ok = c <= 0
+		case ">":
+			ok = c > 0
+		case ">=":
+			ok = c >= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// checkVersionRange reports the first comparison in a constraint that
+// matchVersionRange cannot use.
+func checkVersionRange(constraint string) error {
+	fields := strings.FieldsFunc(constraint, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) == 0 {
+		return errors.New("empty version range")
+	}
+	for _, field := range fields {
+		i := strings.IndexFunc(field, func(r rune) bool { return !strings.ContainsRune("<>=!", r) })
+		if i < 0 {
+			return fmt.Errorf("%q has no version", field)
+		}
+		switch field[:i] {
+		case "", "=", "==", "!=", "<", "<=", ">", ">=":
+		default:
+			return fmt.Errorf("%q has an unknown operator", field)
+		}
+	}
+	return nil
+}
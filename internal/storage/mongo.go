@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"mclogs-go/internal/config"
 	"mclogs-go/internal/models"
 	"time"
@@ -11,9 +12,13 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// mongoBundleCollection holds bundles, next to the logs collection.
+const mongoBundleCollection = "bundles"
+
 type MongoStorage struct {
 	client     *mongo.Client
 	collection *mongo.Collection
+	bundles    *mongo.Collection
 	cfg        *config.StorageConfig
 }
 
@@ -26,23 +31,22 @@ func NewMongoStorage(cfg *config.Config) (*MongoStorage, error) {
 		return nil, err
 	}
 
-	coll := client.Database(cfg.Database.MongoDB.DB).Collection(cfg.Database.MongoDB.Collection)
+	db := client.Database(cfg.Database.MongoDB.DB)
 
 	return &MongoStorage{
 		client:     client,
-		collection: coll,
+		collection: db.Collection(cfg.Database.MongoDB.Collection),
+		bundles:    db.Collection(mongoBundleCollection),
 		cfg:        &cfg.Storage,
 	}, nil
 }
 
-func (s *MongoStorage) Put(ctx context.Context, content string) (string, error) {
+func (s *MongoStorage) Put(ctx context.Context, log *models.Log) (string, error) {
 	rawID := GenerateRawID()
-	id := GetFullID(s.cfg.CurrentID, rawID)
-	log := models.Log{
-		ID:        id,
-		Content:   content,
-		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(time.Duration(s.cfg.TTL) * time.Second),
+	log.ID = GetFullID(s.cfg.CurrentID, rawID)
+	log.CreatedAt = time.Now()
+	if log.ExpiresAt.IsZero() && s.cfg.TTL > 0 {
+		log.ExpiresAt = log.CreatedAt.Add(time.Duration(s.cfg.TTL) * time.Second)
 	}
 
 	_, err := s.collection.InsertOne(ctx, log)
@@ -50,10 +54,39 @@ func (s *MongoStorage) Put(ctx context.Context, content string) (string, error)
 		return "", err
 	}
 
-	return id, nil
+	return log.ID, nil
 }
 
 func (s *MongoStorage) Get(ctx context.Context, id string) (*models.Log, error) {
+	log, err := s.Peek(ctx, id)
+	if err != nil || log == nil || log.MaxViews == 0 {
+		return log, err
+	}
+
+	// Only views below the limit are counted, so concurrent readers cannot
+	// get past it
+	var counted models.Log
+	err = s.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "$expr": bson.M{"$lt": bson.A{"$views", "$max_views"}}},
+		bson.M{"$inc": bson.M{"views": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&counted)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if counted.Views >= counted.MaxViews {
+		if err := s.Delete(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return &counted, nil
+}
+
+func (s *MongoStorage) Peek(ctx context.Context, id string) (*models.Log, error) {
 	var log models.Log
 	err := s.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&log)
 	if err != nil {
@@ -62,6 +95,9 @@ func (s *MongoStorage) Get(ctx context.Context, id string) (*models.Log, error)
 		}
 		return nil, err
 	}
+	if log.Expired() || (log.MaxViews > 0 && log.Views >= log.MaxViews) {
+		return nil, nil
+	}
 	return &log, nil
 }
 
@@ -78,3 +114,36 @@ func (s *MongoStorage) Renew(ctx context.Context, id string) error {
 	)
 	return err
 }
+
+func (s *MongoStorage) PutBundle(ctx context.Context, bundle *models.Bundle) (string, error) {
+	bundle.ID = GetFullID(s.cfg.CurrentID, GenerateRawID())
+	bundle.CreatedAt = time.Now()
+	if bundle.ExpiresAt.IsZero() && s.cfg.TTL > 0 {
+		bundle.ExpiresAt = bundle.CreatedAt.Add(time.Duration(s.cfg.TTL) * time.Second)
+	}
+
+	if _, err := s.bundles.InsertOne(ctx, bundle); err != nil {
+		return "", err
+	}
+	return bundle.ID, nil
+}
+
+func (s *MongoStorage) GetBundle(ctx context.Context, id string) (*models.Bundle, error) {
+	var bundle models.Bundle
+	err := s.bundles.FindOne(ctx, bson.M{"_id": id}).Decode(&bundle)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if bundle.Expired() {
+		return nil, nil
+	}
+	return &bundle, nil
+}
+
+func (s *MongoStorage) DeleteBundle(ctx context.Context, id string) error {
+	_, err := s.bundles.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
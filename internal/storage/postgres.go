@@ -2,11 +2,13 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"mclogs-go/internal/config"
 	"mclogs-go/internal/models"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -74,33 +76,90 @@ func NewPostgresStorage(cfg *config.Config) (*PostgresStorage, error) {
 		return nil, fmt.Errorf("failed to create logs table: %w", err)
 	}
 
+	// Columns added after the first release
+	_, err = pool.Exec(ctx, `
+		ALTER TABLE logs
+			ADD COLUMN IF NOT EXISTS delete_token_hash TEXT NOT NULL DEFAULT '',
+			ADD COLUMN IF NOT EXISTS max_views INTEGER NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS views INTEGER NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS password_hash TEXT NOT NULL DEFAULT ''
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate logs table: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS bundles (
+			id VARCHAR(255) PRIMARY KEY,
+			files JSONB NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP WITH TIME ZONE
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundles table: %w", err)
+	}
+
 	return &PostgresStorage{
 		pool: pool,
 		cfg:  &cfg.Storage,
 	}, nil
 }
 
-func (s *PostgresStorage) Put(ctx context.Context, content string) (string, error) {
+func (s *PostgresStorage) Put(ctx context.Context, log *models.Log) (string, error) {
 	rawID := GenerateRawID()
-	id := GetFullID(s.cfg.CurrentID, rawID)
-	expiresAt := time.Now().Add(time.Duration(s.cfg.TTL) * time.Second)
+	log.ID = GetFullID(s.cfg.CurrentID, rawID)
+	log.CreatedAt = time.Now()
+	if log.ExpiresAt.IsZero() && s.cfg.TTL > 0 {
+		log.ExpiresAt = log.CreatedAt.Add(time.Duration(s.cfg.TTL) * time.Second)
+	}
 
-	_, err := s.pool.Exec(ctx, "INSERT INTO logs (id, content, expires_at) VALUES ($1, $2, $3)", id, content, expiresAt)
+	_, err := s.pool.Exec(ctx,
+		"INSERT INTO logs (id, content, created_at, expires_at, delete_token_hash, max_views, password_hash) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		log.ID, log.Content, log.CreatedAt, log.ExpiresAt, log.DeleteTokenHash, log.MaxViews, log.PasswordHash,
+	)
 	if err != nil {
 		return "", err
 	}
 
-	return id, nil
+	return log.ID, nil
 }
 
 func (s *PostgresStorage) Get(ctx context.Context, id string) (*models.Log, error) {
+	log, err := s.Peek(ctx, id)
+	if err != nil || log == nil || log.MaxViews == 0 {
+		return log, err
+	}
+
+	// Only views below the limit are counted, so concurrent readers cannot
+	// get past it
+	err = s.pool.QueryRow(ctx, "UPDATE logs SET views = views + 1 WHERE id = $1 AND views < max_views RETURNING views", id).Scan(&log.Views)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if log.Views >= log.MaxViews {
+		if err := s.Delete(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return log, nil
+}
+
+func (s *PostgresStorage) Peek(ctx context.Context, id string) (*models.Log, error) {
 	var log models.Log
-	err := s.pool.QueryRow(ctx, "SELECT id, content, created_at, expires_at FROM logs WHERE id = $1", id).Scan(
-		&log.ID, &log.Content, &log.CreatedAt, &log.ExpiresAt,
+	err := s.pool.QueryRow(ctx, "SELECT id, content, created_at, expires_at, delete_token_hash, max_views, views, password_hash FROM logs WHERE id = $1", id).Scan(
+		&log.ID, &log.Content, &log.CreatedAt, &log.ExpiresAt, &log.DeleteTokenHash, &log.MaxViews, &log.Views, &log.PasswordHash,
 	)
 	if err != nil {
 		// pgx returns err if no rows found
-		return nil, nil 
+		return nil, nil
+	}
+	if log.Expired() || (log.MaxViews > 0 && log.Views >= log.MaxViews) {
+		return nil, nil
 	}
 	return &log, nil
 }
@@ -115,3 +174,42 @@ func (s *PostgresStorage) Renew(ctx context.Context, id string) error {
 	_, err := s.pool.Exec(ctx, "UPDATE logs SET expires_at = $1 WHERE id = $2", expiresAt, id)
 	return err
 }
+
+func (s *PostgresStorage) PutBundle(ctx context.Context, bundle *models.Bundle) (string, error) {
+	bundle.ID = GetFullID(s.cfg.CurrentID, GenerateRawID())
+	bundle.CreatedAt = time.Now()
+	if bundle.ExpiresAt.IsZero() && s.cfg.TTL > 0 {
+		bundle.ExpiresAt = bundle.CreatedAt.Add(time.Duration(s.cfg.TTL) * time.Second)
+	}
+
+	_, err := s.pool.Exec(ctx,
+		"INSERT INTO bundles (id, files, created_at, expires_at) VALUES ($1, $2, $3, $4)",
+		bundle.ID, bundle.Files, bundle.CreatedAt, bundle.ExpiresAt,
+	)
+	if err != nil {
+		return "", err
+	}
+	return bundle.ID, nil
+}
+
+func (s *PostgresStorage) GetBundle(ctx context.Context, id string) (*models.Bundle, error) {
+	var bundle models.Bundle
+	err := s.pool.QueryRow(ctx, "SELECT id, files, created_at, expires_at FROM bundles WHERE id = $1", id).Scan(
+		&bundle.ID, &bundle.Files, &bundle.CreatedAt, &bundle.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if bundle.Expired() {
+		return nil, nil
+	}
+	return &bundle, nil
+}
+
+func (s *PostgresStorage) DeleteBundle(ctx context.Context, id string) error {
+	_, err := s.pool.Exec(ctx, "DELETE FROM bundles WHERE id = $1", id)
+	return err
+}
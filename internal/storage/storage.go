@@ -6,8 +6,21 @@ import (
 )
 
 type Storage interface {
-	Put(ctx context.Context, content string) (string, error)
+	// Put stores a new log, filling in its ID and CreatedAt, and ExpiresAt
+	// unless it is already set
+	Put(ctx context.Context, log *models.Log) (string, error)
+	// Get reads a log as a view: for a log with MaxViews it counts the view
+	// and deletes the log on the last one. Expired and used up logs are nil.
 	Get(ctx context.Context, id string) (*models.Log, error)
+	// Peek reads a log without counting a view
+	Peek(ctx context.Context, id string) (*models.Log, error)
 	Delete(ctx context.Context, id string) error
 	Renew(ctx context.Context, id string) error
+	// PutBundle stores a bundle of already stored logs, filling in its ID
+	// and CreatedAt, and ExpiresAt unless it is already set
+	PutBundle(ctx context.Context, bundle *models.Bundle) (string, error)
+	// GetBundle reads a bundle; expired bundles are nil
+	GetBundle(ctx context.Context, id string) (*models.Bundle, error)
+	// DeleteBundle deletes a bundle, but not its logs
+	DeleteBundle(ctx context.Context, id string) error
 }
@@ -27,13 +27,30 @@ export const apiEndpoints = [
         field: 'content',
         type: 'string',
         description: '原始日志文件内容字符串。最大长度为10MiB和25k行，必要时将被截断。'
+      },
+      {
+        field: 'expires_in',
+        type: 'integer',
+        description: '可选。日志在多少秒后过期，超出服务器允许的范围时会被调整，见 /1/limits。'
+      },
+      {
+        field: 'max_views',
+        type: 'integer',
+        description: '可选。原始日志被查看多少次后删除，1 即阅后即焚。只有 /1/raw 计入次数，洞察和 AI 分析不计入。'
+      },
+      {
+        field: 'password',
+        type: 'string',
+        description: '可选。设置后读取日志需要在 X-Log-Password 请求头中提供密码，或使用 /1/unlock/[id] 换取的访问令牌。'
       }
     ],
     successResponse: {
       success: true,
       id: "8FlTowW",
-      url: "https://mclogs.lemwood.icu/8FlTowW",
-      raw: "https://api.mclogs.lemwood.icu/1/raw/8FlTowW"
+      url: "https://mclogs.lemwood.icu/#/8FlTowW",
+      raw: "https://api.mclogs.lemwood.icu/1/raw/8FlTowW",
+      insights: "https://api.mclogs.lemwood.icu/1/insights/8FlTowW",
+      delete_token: "dGhpcyBpcyBub3QgYSByZWFsIHRva2Vu..."
     },
     errorResponse: {
       success: false,
@@ -90,6 +107,42 @@ export const apiEndpoints = [
       }
     ]
   },
+  {
+    id: 'bundle',
+    title: '上传日志包',
+    method: 'POST',
+    url: 'https://api.mclogs.lemwood.icu/1/bundle',
+    description: '一次上传多个相关文件（如 latest.log 和崩溃报告），每个文件单独保存和分析。可使用 multipart 的 files 字段，或 JSON。',
+    params: [
+      {
+        field: 'files',
+        type: 'array',
+        description: '最多 10 个文件，请求体上限见 /1/limits 的 maxBundleBodySize。JSON 格式为 [{ "name": "latest.log", "content": "..." }]'
+      },
+      {
+        field: 'expires_in',
+        type: 'integer',
+        description: '可选。日志包在多少秒后过期'
+      }
+    ],
+    successResponse: {
+      success: true,
+      id: "Qw3rTy9",
+      url: "https://api.mclogs.lemwood.icu/1/bundle/Qw3rTy9",
+      insights: "https://api.mclogs.lemwood.icu/1/bundle/Qw3rTy9/insights",
+      files: [
+        { name: "latest.log", id: "8FlTowW", url: "https://mclogs.lemwood.icu/#/8FlTowW" }
+      ],
+      delete_token: "dGhpcyBpcyBub3QgYSByZWFsIHRva2Vu..."
+    }
+  },
+  {
+    id: 'bundle-insights',
+    title: '获取日志包分析',
+    method: 'GET',
+    url: 'https://api.mclogs.lemwood.icu/1/bundle/[id]/insights',
+    description: '返回每个文件的分析结果，以及合并后的结果（merged）：崩溃报告的根本原因与 latest.log 的模组列表会一起显示。'
+  },
   {
     id: 'rate-error',
     title: '速率限制错误信息',
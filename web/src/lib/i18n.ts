@@ -85,6 +85,11 @@ export const zhCN = {
   'get_limits_desc': '获取当前服务器配置的日志存储限制参数。',
 
   'log_not_found': '日志未找到或网络错误',
+  'log_password_required': '此日志受密码保护',
+  'log_password_placeholder': '请输入密码',
+  'log_unlock': '解锁',
+  'action_install': '安装',
+  'action_remove': '移除',
   'analysis_failed': '分析失败',
   'network_error': '网络错误',
 
@@ -182,6 +187,11 @@ export const zhTW = {
   'get_limits_desc': '取得目前伺服器設定的記錄儲存限制參數。',
 
   'log_not_found': '記錄未找到或網路錯誤',
+  'log_password_required': '此記錄受密碼保護',
+  'log_password_placeholder': '請輸入密碼',
+  'log_unlock': '解鎖',
+  'action_install': '安裝',
+  'action_remove': '移除',
   'analysis_failed': '分析失敗',
   'network_error': '網路錯誤',
 
@@ -6,6 +6,7 @@
 export const LOCAL_STORAGE_KEYS = {
   AI_ANALYSIS_HISTORY: 'ai_analysis_history',
   USER_LOG_RECORDS: 'user_log_records',
+  DELETE_TOKENS: 'delete_tokens',
   PAGE_TITLE: 'page_title'
 };
 
@@ -162,6 +163,50 @@ export const deleteUserLogRecord = (id: string): void => {
   }
 };
 
+/**
+ * 保存上传日志时返回的删除令牌
+ * @param logId 日志ID
+ * @param token 删除令牌
+ */
+export const saveDeleteToken = (logId: string, token: string): void => {
+  try {
+    const tokens: Record<string, string> = JSON.parse(localStorage.getItem(LOCAL_STORAGE_KEYS.DELETE_TOKENS) || '{}');
+    tokens[logId] = token;
+    localStorage.setItem(LOCAL_STORAGE_KEYS.DELETE_TOKENS, JSON.stringify(tokens));
+  } catch (error) {
+    console.error('保存删除令牌失败:', error);
+  }
+};
+
+/**
+ * 获取日志的删除令牌，只有在本浏览器上传的日志才有
+ * @param logId 日志ID
+ * @returns 删除令牌，没有时返回 null
+ */
+export const getDeleteToken = (logId: string): string | null => {
+  try {
+    const tokens: Record<string, string> = JSON.parse(localStorage.getItem(LOCAL_STORAGE_KEYS.DELETE_TOKENS) || '{}');
+    return tokens[logId] || null;
+  } catch (error) {
+    console.error('获取删除令牌失败:', error);
+    return null;
+  }
+};
+
+/**
+ * 删除日志后移除其删除令牌
+ * @param logId 日志ID
+ */
+export const removeDeleteToken = (logId: string): void => {
+  try {
+    const tokens: Record<string, string> = JSON.parse(localStorage.getItem(LOCAL_STORAGE_KEYS.DELETE_TOKENS) || '{}');
+    delete tokens[logId];
+    localStorage.setItem(LOCAL_STORAGE_KEYS.DELETE_TOKENS, JSON.stringify(tokens));
+  } catch (error) {
+    console.error('移除删除令牌失败:', error);
+  }
+};
+
 /**
  * 清空所有本地存储数据
  */